@@ -7,10 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"slices"
 	"time"
 
 	auth "GoServer/internal/auth"
+	"GoServer/internal/entities"
+	"GoServer/internal/pagination"
+	"GoServer/internal/textlen"
 
 	"github.com/google/uuid"
 )
@@ -72,104 +74,278 @@ func (cfg *apiConfig) createUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := cfg.DB.CreateUser(r.Context(), database.CreateUserParams{Email: userUnmarshallInto.Email, HashedPassword: hashedPassword})
+	if userUnmarshallInto.Handle != "" && !entities.ValidHandle(userUnmarshallInto.Handle) {
+		respondWithError(w, http.StatusBadRequest, "Invalid handle", nil)
+		return
+	}
+
+	var user database.User
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		user, err = q.CreateUser(r.Context(), database.CreateUserParams{Email: userUnmarshallInto.Email, HashedPassword: hashedPassword})
+		if err != nil || userUnmarshallInto.Handle == "" {
+			return err
+		}
+		user, err = q.SetUserHandle(r.Context(), database.SetUserHandleParams{
+			Handle: sql.NullString{String: userUnmarshallInto.Handle, Valid: true},
+			ID:     user.ID,
+		})
+		return err
+	})
 	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "Email or handle already taken", nil)
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "Error creating User", err)
+		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, User{ID: user.ID, CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt, Email: user.Email, IsChirpyRed: user.IsChirpyRed})
+	respondWithJSON(w, http.StatusCreated, User{ID: user.ID, CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt, Email: user.Email, IsChirpyRed: user.IsChirpyRed, Handle: user.Handle.String, ExpandContentWarnings: user.ExpandContentWarnings})
 }
 
-func (cfg *apiConfig) createChirp(w http.ResponseWriter, r *http.Request) {
+// requireUserID validates the caller's access token, responding with 401 and
+// returning false when it is missing or invalid.
+func (cfg *apiConfig) requireUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	user, ok := cfg.requireUser(w, r)
+	return user.ID, ok
+}
+
+// requireUser is requireUserID returning the caller's account. Suspended
+// accounts get 403, so that a suspension takes effect before the access
+// tokens issued earlier expire.
+func (cfg *apiConfig) requireUser(w http.ResponseWriter, r *http.Request) (database.User, bool) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Error getting token", err)
-		return
+		return database.User{}, false
 	}
 
-	uuid, err := auth.ValidateJWT(token, cfg.Secret)
+	userID, err := auth.ValidateJWT(token, cfg.Secret)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Error validating token", err)
+		return database.User{}, false
+	}
+
+	user, err := cfg.DB.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusUnauthorized, "User not found", nil)
+			return database.User{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving user", err)
+		return database.User{}, false
+	}
+	if suspended(user, time.Now()) {
+		respondWithError(w, http.StatusForbidden, "Account suspended until "+user.SuspendedUntil.Time.Format(time.RFC3339), nil)
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// optionalUserID is requireUserID for endpoints that also serve anonymous
+// callers, who get uuid.Nil. A token that is present but invalid is still
+// rejected.
+func (cfg *apiConfig) optionalUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	if r.Header.Get("Authorization") == "" {
+		return uuid.Nil, true
+	}
+	return cfg.requireUserID(w, r)
+}
+
+// chirpRequestOverhead is the room a chirp request gets besides its body,
+// for polls, media IDs and the other fields.
+const chirpRequestOverhead = 16 << 10
+
+// maxChirpRequestBytes caps the size of requests carrying a chirp body at
+// twice what the longest body any account may post takes, leaving room for
+// JSON escapes.
+func (cfg *apiConfig) maxChirpRequestBytes() int64 {
+	return 2*int64(max(cfg.ChirpMaxLength, cfg.ChirpMaxLengthRed))*textlen.MaxBytesPerChar + chirpRequestOverhead
+}
+
+func validateChirpBody(body string, limit int) error {
+	if !textlen.Fits(body, limit) {
+		return errors.New("Chirp is too long")
+	}
+	return nil
+}
+
+func (cfg *apiConfig) createChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
 		return
 	}
 
-	chirpUnmarshallInto := Chirp{}
-	if err := json.NewDecoder(r.Body).Decode(&chirpUnmarshallInto); err != nil {
+	params := struct {
+		Body      string      `json:"body"`
+		MediaIDs  []uuid.UUID `json:"media_ids"`
+		PublishAt *time.Time  `json:"publish_at"`
+		Poll      *PollParams `json:"poll"`
+		// Visibility defaults to public.
+		Visibility     string `json:"visibility"`
+		ContentWarning string `json:"content_warning"`
+		Sensitive      bool   `json:"sensitive"`
+	}{}
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.maxChirpRequestBytes())
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error unmarshalling Chirp", err)
+		return
+	}
+	if len(params.MediaIDs) > maxChirpMedia {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("A chirp can have at most %d media attachments", maxChirpMedia), nil)
+		return
+	}
+	publishAt, err := parsePublishAt(params.PublishAt)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	visibility, err := parseVisibility(params.Visibility)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
 	}
-	const maxChirpLen = 140
-	if len(chirpUnmarshallInto.Body) > maxChirpLen {
-		respondWithError(w, http.StatusBadRequest, "Chirp is too long", nil)
+	warning, err := cfg.parseContentWarning(params.ContentWarning, params.Sensitive)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if params.Poll != nil {
+		if err := validatePoll(params.Poll, publishAt); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+	}
+	limit, err := cfg.chirpLengthLimit(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving user", err)
 		return
 	}
+	outcome, err := cfg.checkChirpBody(params.Body, limit)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	outcome.Decisions = append(outcome.Decisions, warning.Decisions...)
 
-	chirp, err := cfg.DB.CreateChirp(r.Context(), database.CreateChirpParams{Body: chirpUnmarshallInto.Body, UserID: uuid})
+	var chirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		chirp, err = cfg.insertChirp(r.Context(), q, database.CreateChirpParams{Body: outcome.Body, UserID: userID, PublishAt: publishAt, Visibility: visibility, ContentWarning: warning.Text, Sensitive: warning.Sensitive}, outcome.Decisions)
+		if err != nil {
+			return err
+		}
+		if err := attachMedia(r.Context(), q, userID, chirp.ID, params.MediaIDs); err != nil {
+			return err
+		}
+		return insertPoll(r.Context(), q, chirp.ID, params.Poll)
+	})
 	if err != nil {
+		if errors.Is(err, errMediaNotAttachable) {
+			respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "Error creating chirp", err)
+		return
 	}
 
-	chirpResponse := Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserId:    chirp.UserID,
+	chirpResponse, err := cfg.buildChirp(r.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
 	}
-
+	chirpResponse.Moderation = outcome.Decisions
 	respondWithJSON(w, http.StatusCreated, chirpResponse)
 }
 
 func (cfg *apiConfig) getChirps(w http.ResponseWriter, r *http.Request) {
-	s := r.URL.Query().Get("author_id")
-	order := r.URL.Query().Get("sort")
-	if s != "" {
-		chirps, err := cfg.DB.GetChirpsByUserID(r.Context(), uuid.MustParse(s))
-		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "Couldn't get all chirps", err)
-			return
-		}
-		chirpsResponse := make([]Chirp, len(chirps))
-		for i, chirp := range chirps {
-			chirpsResponse[i] = Chirp{
-				ID:        chirp.ID,
-				CreatedAt: chirp.CreatedAt,
-				UpdatedAt: chirp.UpdatedAt,
-				Body:      chirp.Body,
-				UserId:    chirp.UserID,
-			}
-		}
-		if order == "asc" || order == "" {
-			respondWithJSON(w, http.StatusOK, chirpsResponse)
-			return
-		}
-		slices.Reverse(chirpsResponse)
-		respondWithJSON(w, http.StatusOK, chirpsResponse)
+	viewerID, ok := cfg.optionalUserID(w, r)
+	if !ok {
 		return
 	}
 
-	chirps, err := cfg.DB.GetChirps(r.Context())
+	query := r.URL.Query()
+	page, err := pagination.ParseParams(query)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't get all chirps", err)
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
 	}
-	chirpsResponse := make([]Chirp, len(chirps))
-	for i, chirp := range chirps {
-		chirpsResponse[i] = Chirp{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserId:    chirp.UserID,
-		}
+
+	order := query.Get("sort")
+	if order != "" && order != "asc" && order != "desc" && order != "engagement" {
+		respondWithError(w, http.StatusBadRequest, "sort must be asc, desc or engagement", nil)
+		return
+	}
+
+	filters, err := parseChirpFilters(query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if order == "engagement" {
+		cfg.getChirpsByEngagement(w, r, viewerID, page, filters)
+		return
+	}
+
+	cursorCreatedAt, cursorID := sql.NullTime{}, uuid.NullUUID{}
+	if page.Cursor != nil {
+		cursorCreatedAt = sql.NullTime{Time: page.Cursor.CreatedAt, Valid: true}
+		cursorID = uuid.NullUUID{UUID: page.Cursor.ID, Valid: true}
+	}
+
+	var chirps []database.Chirp
+	if page.Descending(order == "desc") {
+		chirps, err = cfg.DB.ListChirpsBefore(r.Context(), database.ListChirpsBeforeParams{
+			ViewerID:        viewerID,
+			AuthorIds:       filters.AuthorIDs,
+			Since:           filters.Since,
+			Until:           filters.Until,
+			HasMedia:        filters.HasMedia,
+			IsReply:         filters.IsReply,
+			Tag:             filters.Tag,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			RowLimit:        int32(page.Limit + 1),
+		})
+	} else {
+		chirps, err = cfg.DB.ListChirpsAfter(r.Context(), database.ListChirpsAfterParams{
+			ViewerID:        viewerID,
+			AuthorIds:       filters.AuthorIDs,
+			Since:           filters.Since,
+			Until:           filters.Until,
+			HasMedia:        filters.HasMedia,
+			IsReply:         filters.IsReply,
+			Tag:             filters.Tag,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			RowLimit:        int32(page.Limit + 1),
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get all chirps", err)
+		return
 	}
-	if order == "asc" || order == "" {
-		respondWithJSON(w, http.StatusOK, chirpsResponse)
+
+	chirps, nextCursor, prevCursor := pagination.Window(chirps, page, chirpCursor)
+	chirpsResponse, err := cfg.buildChirps(r.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get all chirps", err)
 		return
 	}
-	slices.Reverse(chirpsResponse)
-	respondWithJSON(w, http.StatusOK, chirpsResponse)
+
+	pagination.SetLinkHeader(w, r, nextCursor, prevCursor)
+	respondWithJSON(w, http.StatusOK, pagination.Page[Chirp]{
+		Items:      chirpsResponse,
+		NextCursor: nextCursor,
+		PrevCursor: prevCursor,
+	})
 }
 
 func (cfg *apiConfig) getChirpByID(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.optionalUserID(w, r)
+	if !ok {
+		return
+	}
 
 	chirpIDStr := r.PathValue("chirpID")
 
@@ -179,7 +355,7 @@ func (cfg *apiConfig) getChirpByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	chirp, err := cfg.DB.GetChirpByID(r.Context(), chirpID)
+	chirp, err := cfg.DB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{ID: chirpID, ViewerID: viewerID})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
@@ -189,12 +365,10 @@ func (cfg *apiConfig) getChirpByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	chirpResponse := Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserId:    chirp.UserID,
+	chirpResponse, err := cfg.buildChirp(r.Context(), viewerID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
 	}
 
 	respondWithJSON(w, http.StatusOK, chirpResponse)
@@ -223,6 +397,10 @@ func (cfg *apiConfig) login(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusUnauthorized, "WRONG", err)
 		return
 	}
+	if suspended(user, time.Now()) {
+		respondWithError(w, http.StatusForbidden, "Account suspended until "+user.SuspendedUntil.Time.Format(time.RFC3339), nil)
+		return
+	}
 
 	token, err := auth.MakeJWT(user.ID, cfg.Secret, time.Hour)
 	if err != nil {
@@ -234,7 +412,7 @@ func (cfg *apiConfig) login(w http.ResponseWriter, r *http.Request) {
 
 	cfg.DB.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{Token: refresh_token, UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour * 1440)})
 
-	respondWithJSON(w, http.StatusOK, User{ID: user.ID, CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt, Email: user.Email, AccessToken: token, RefreshToken: refresh_token, IsChirpyRed: user.IsChirpyRed})
+	respondWithJSON(w, http.StatusOK, User{ID: user.ID, CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt, Email: user.Email, AccessToken: token, RefreshToken: refresh_token, IsChirpyRed: user.IsChirpyRed, Handle: user.Handle.String, ExpandContentWarnings: user.ExpandContentWarnings})
 
 }
 
@@ -266,6 +444,16 @@ func (cfg *apiConfig) refresh(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userID := refreshTokenFromDB.UserID
+	user, err := cfg.DB.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving user", err)
+		return
+	}
+	if suspended(user, time.Now()) {
+		respondWithError(w, http.StatusForbidden, "Account suspended", nil)
+		return
+	}
+
 	accessToken, err := auth.MakeJWT(userID, cfg.Secret, time.Hour)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error making JWT", err)
@@ -305,15 +493,17 @@ func (cfg *apiConfig) revoke(w http.ResponseWriter, r *http.Request) {
 }
 
 func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request) {
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Error getting token", err)
+	userUUID, ok := cfg.requireUserID(w, r)
+	if !ok {
 		return
 	}
 
 	params := struct {
-		Email          string `json:"email"`
-		HashedPassword string `json:"password"`
+		Email          string  `json:"email"`
+		HashedPassword string  `json:"password"`
+		Handle         *string `json:"handle"`
+		// ExpandContentWarnings is left unchanged when omitted.
+		ExpandContentWarnings *bool `json:"expand_content_warnings"`
 	}{
 		Email:          "",
 		HashedPassword: "",
@@ -322,10 +512,8 @@ func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusInternalServerError, "Error unmarshalling login parameters", err)
 		return
 	}
-
-	userUUID, err := auth.ValidateJWT(token, cfg.Secret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Error validating token", err)
+	if params.Handle != nil && *params.Handle != "" && !entities.ValidHandle(*params.Handle) {
+		respondWithError(w, http.StatusBadRequest, "Invalid handle", nil)
 		return
 	}
 
@@ -335,25 +523,45 @@ func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newUser, err := cfg.DB.UpdateUser(r.Context(), database.UpdateUserParams{ID: userUUID, Email: params.Email, HashedPassword: hashedPassword})
+	var newUser database.User
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		newUser, err = q.UpdateUser(r.Context(), database.UpdateUserParams{ID: userUUID, Email: params.Email, HashedPassword: hashedPassword})
+		if err != nil {
+			return err
+		}
+		if params.ExpandContentWarnings != nil {
+			newUser, err = q.SetUserExpandContentWarnings(r.Context(), database.SetUserExpandContentWarningsParams{
+				ExpandContentWarnings: *params.ExpandContentWarnings,
+				ID:                    userUUID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if params.Handle == nil {
+			return nil
+		}
+		newUser, err = q.SetUserHandle(r.Context(), database.SetUserHandleParams{
+			Handle: sql.NullString{String: *params.Handle, Valid: *params.Handle != ""},
+			ID:     userUUID,
+		})
+		return err
+	})
 	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "Email or handle already taken", nil)
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "Error updating user", err)
 		return
 	}
-	respondWithJSON(w, http.StatusOK, User{ID: newUser.ID, CreatedAt: newUser.CreatedAt, UpdatedAt: newUser.UpdatedAt, Email: newUser.Email, IsChirpyRed: newUser.IsChirpyRed})
+	respondWithJSON(w, http.StatusOK, User{ID: newUser.ID, CreatedAt: newUser.CreatedAt, UpdatedAt: newUser.UpdatedAt, Email: newUser.Email, IsChirpyRed: newUser.IsChirpyRed, Handle: newUser.Handle.String, ExpandContentWarnings: newUser.ExpandContentWarnings})
 
 }
 
 func (cfg *apiConfig) deleteChirp(w http.ResponseWriter, r *http.Request) {
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Error getting token", err)
-		return
-	}
-
-	userUUID, err := auth.ValidateJWT(token, cfg.Secret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Error validating token", err)
+	userUUID, ok := cfg.requireUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -378,7 +586,14 @@ func (cfg *apiConfig) deleteChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cfg.DB.DeleteChirpByID(r.Context(), chirpID)
+	if err := cfg.removeChirp(r.Context(), chirp, userUUID, deletionByAuthor, authorDeletionReason); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error deleting chirp", err)
+		return
+	}
 	respondWithJSON(w, http.StatusNoContent, nil)
 
 }
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/pagination"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) bookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return
+	}
+
+	chirp, err := cfg.DB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{ID: chirpID, ViewerID: userID})
+	if err == nil && (chirp.DeletedAt.Valid || chirp.PublishAt.Valid) {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+
+	if err := cfg.DB.BookmarkChirp(r.Context(), database.BookmarkChirpParams{UserID: userID, ChirpID: chirpID}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error bookmarking chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) unbookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return
+	}
+
+	if err := cfg.DB.UnbookmarkChirp(r.Context(), database.UnbookmarkChirpParams{UserID: userID, ChirpID: chirpID}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error removing bookmark", err)
+		return
+	}
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// getBookmarks lists the caller's bookmarks, newest first. Bookmarked chirps
+// that were deleted show up as tombstones until the purge job removes them,
+// which also removes the bookmark.
+func (cfg *apiConfig) getBookmarks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if page.Cursor != nil && page.Cursor.Backward {
+		respondWithError(w, http.StatusBadRequest, "Bookmarks can only be paged forward", nil)
+		return
+	}
+
+	params := database.ListBookmarksParams{UserID: userID, RowLimit: int32(page.Limit + 1)}
+	if page.Cursor != nil {
+		params.CursorCreatedAt = sql.NullTime{Time: page.Cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.Cursor.ID, Valid: true}
+	}
+	bookmarks, err := cfg.DB.ListBookmarks(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving bookmarks", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(bookmarks) > page.Limit {
+		bookmarks = bookmarks[:page.Limit]
+		last := bookmarks[len(bookmarks)-1]
+		nextCursor = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ChirpID}.Encode()
+	}
+
+	ids := make([]uuid.UUID, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		ids[i] = bookmark.ChirpID
+	}
+	chirps, err := cfg.getChirpsInOrder(r.Context(), userID, ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving bookmarks", err)
+		return
+	}
+	chirpsResponse, err := cfg.buildChirps(r.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving bookmarks", err)
+		return
+	}
+
+	pagination.SetLinkHeader(w, r, nextCursor, "")
+	respondWithJSON(w, http.StatusOK, pagination.Page[Chirp]{
+		Items:      chirpsResponse,
+		NextCursor: nextCursor,
+	})
+}
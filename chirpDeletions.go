@@ -0,0 +1,191 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Deletion kinds record who removed a chirp: its author, or a moderator
+// taking it down, whoever the moderator is.
+const (
+	deletionByAuthor    = "author"
+	deletionByModerator = "moderator"
+)
+
+const (
+	authorDeletionReason = "deleted by author"
+	maxDeletionReason    = 500
+	purgeBatchSize       = 500
+)
+
+// removeChirp soft-deletes a chirp on behalf of actorID as a deletion of the
+// given kind. The chirp stays as a tombstone, keeping its place in threads,
+// and can be restored until the purge job removes it after the retention
+// window. It returns sql.ErrNoRows if the chirp is already deleted.
+func (cfg *apiConfig) removeChirp(ctx context.Context, chirp database.Chirp, actorID uuid.UUID, kind, reason string) error {
+	return cfg.withTx(ctx, func(q *database.Queries) error {
+		return softDeleteChirp(ctx, q, chirp.ID, actorID, kind, reason)
+	})
+}
+
+// softDeleteChirp is removeChirp for callers that already hold a
+// transaction.
+func softDeleteChirp(ctx context.Context, q *database.Queries, chirpID, actorID uuid.UUID, kind, reason string) error {
+	deleted, err := q.SoftDeleteChirp(ctx, database.SoftDeleteChirpParams{
+		DeletedBy:      uuid.NullUUID{UUID: actorID, Valid: true},
+		DeletionKind:   sql.NullString{String: kind, Valid: true},
+		DeletionReason: sql.NullString{String: reason, Valid: true},
+		ID:             chirpID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+	if err := q.DeleteChirpTags(ctx, chirpID); err != nil {
+		return err
+	}
+	return q.DeleteChirpMentions(ctx, chirpID)
+}
+
+// takedownChirp lets moderators remove another user's chirp. Unlike author
+// deletes, takedowns keep showing in listings as tombstones with the reason.
+func (cfg *apiConfig) takedownChirp(w http.ResponseWriter, r *http.Request) {
+	moderatorID, ok := cfg.requireRole(w, r, roleModerator)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return
+	}
+
+	params := struct {
+		Reason string `json:"reason"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error unmarshalling takedown", err)
+		return
+	}
+	params.Reason = strings.TrimSpace(params.Reason)
+	if params.Reason == "" || len(params.Reason) > maxDeletionReason {
+		respondWithError(w, http.StatusBadRequest, "A reason of at most 500 bytes is required", nil)
+		return
+	}
+
+	chirp, err := cfg.DB.GetChirpByID(r.Context(), chirpID)
+	if err == nil {
+		err = cfg.removeChirp(r.Context(), chirp, moderatorID, deletionByModerator, params.Reason)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error taking down chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) restoreChirp(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := cfg.requireRole(w, r, roleAdmin)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return
+	}
+
+	var chirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		chirp, err = q.RestoreChirp(r.Context(), database.RestoreChirpParams{
+			ID:               chirpID,
+			RetentionSeconds: cfg.ChirpRetention.Seconds(),
+		})
+		if err != nil {
+			return err
+		}
+		return cfg.indexChirpEntities(r.Context(), q, chirp)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "No restorable chirp found", nil)
+			return
+		}
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "The user has rechirped this chirp again", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error restoring chirp", err)
+		return
+	}
+
+	chirpResponse, err := cfg.buildChirp(r.Context(), adminID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, chirpResponse)
+}
+
+// runPurger removes chirps deleted longer than the retention window ago,
+// each interval until ctx is done.
+func (cfg *apiConfig) runPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := cfg.purgeDeletedChirps(ctx); err != nil {
+			log.Printf("Error purging deleted chirps: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeDeletedChirps hard-deletes expired chirps. Chirps that still have
+// replies are kept as empty tombstones so the threads below them survive;
+// they are purged once their replies are gone. Reported chirps are kept
+// whole as the evidence of their moderation cases.
+func (cfg *apiConfig) purgeDeletedChirps(ctx context.Context) error {
+	retention := cfg.ChirpRetention.Seconds()
+	for {
+		purged, err := cfg.DB.PurgeDeletedChirps(ctx, database.PurgeDeletedChirpsParams{
+			RetentionSeconds: retention,
+			RowLimit:         purgeBatchSize,
+		})
+		if err != nil {
+			return err
+		}
+		if purged < purgeBatchSize {
+			break
+		}
+	}
+
+	return cfg.withTx(ctx, func(q *database.Queries) error {
+		if _, err := q.ScrubDeletedChirps(ctx, retention); err != nil {
+			return err
+		}
+		_, err := q.PurgeDeletedChirpRevisions(ctx, retention)
+		return err
+	})
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ChirpRevision struct {
+	ID         uuid.UUID `json:"id"`
+	ChirpID    uuid.UUID `json:"chirp_id"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+func (cfg *apiConfig) updateChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return
+	}
+
+	params := struct {
+		Body string `json:"body"`
+		// ContentWarning and Sensitive are left as they are when not given.
+		ContentWarning *string `json:"content_warning"`
+		Sensitive      *bool   `json:"sensitive"`
+	}{}
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.maxChirpRequestBytes())
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error unmarshalling Chirp", err)
+		return
+	}
+	limit, err := cfg.chirpLengthLimit(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving user", err)
+		return
+	}
+	outcome, err := cfg.checkChirpBody(params.Body, limit)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	tx, err := cfg.DBConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error starting transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIDForUpdate(r.Context(), chirpID)
+	if err == nil && chirp.DeletedAt.Valid {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "Cannot edit another user's chirp", nil)
+		return
+	}
+	if chirp.RechirpOfID.Valid {
+		respondWithError(w, http.StatusBadRequest, "Rechirps cannot be edited", nil)
+		return
+	}
+	if cfg.ChirpEditWindow > 0 && time.Since(chirp.CreatedAt) > cfg.ChirpEditWindow {
+		respondWithError(w, http.StatusForbidden, "Edit window has closed", nil)
+		return
+	}
+	if cfg.ChirpMaxEdits > 0 && int(chirp.EditCount) >= cfg.ChirpMaxEdits {
+		respondWithError(w, http.StatusForbidden, "Chirp has reached the edit limit", nil)
+		return
+	}
+	warning := contentWarning{Text: chirp.ContentWarning, Sensitive: chirp.Sensitive}
+	if params.ContentWarning != nil || params.Sensitive != nil {
+		if chirp.ModeratorWarning {
+			respondWithError(w, http.StatusForbidden, "Content warning was set by a moderator", nil)
+			return
+		}
+		text, sensitive := chirp.ContentWarning.String, chirp.Sensitive
+		if params.ContentWarning != nil {
+			text = *params.ContentWarning
+		}
+		if params.Sensitive != nil {
+			sensitive = *params.Sensitive
+		}
+		warning, err = cfg.parseContentWarning(text, sensitive)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		outcome.Decisions = append(outcome.Decisions, warning.Decisions...)
+	}
+
+	if _, err := qtx.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+		ChirpID:   chirp.ID,
+		Body:      chirp.Body,
+		CreatedAt: chirp.UpdatedAt,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving chirp revision", err)
+		return
+	}
+
+	updated, err := qtx.UpdateChirpContent(r.Context(), database.UpdateChirpContentParams{
+		Body:           outcome.Body,
+		ContentWarning: warning.Text,
+		Sensitive:      warning.Sensitive,
+		ID:             chirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating chirp", err)
+		return
+	}
+	if err := cfg.indexChirpEntities(r.Context(), qtx, updated); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating chirp", err)
+		return
+	}
+	if err := recordModerationFlags(r.Context(), qtx, updated.ID, outcome.Decisions); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating chirp", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error committing transaction", err)
+		return
+	}
+
+	chirpResponse, err := cfg.buildChirp(r.Context(), userID, updated)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+	chirpResponse.Moderation = outcome.Decisions
+	respondWithJSON(w, http.StatusOK, chirpResponse)
+}
+
+func (cfg *apiConfig) getChirpHistory(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.optionalUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return
+	}
+
+	chirp, err := cfg.DB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{ID: chirpID, ViewerID: viewerID})
+	if err == nil && chirp.DeletedAt.Valid {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+
+	revisions, err := cfg.DB.GetChirpRevisions(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp history", err)
+		return
+	}
+
+	history := make([]ChirpRevision, len(revisions))
+	for i, revision := range revisions {
+		history[i] = ChirpRevision{
+			ID:         revision.ID,
+			ChirpID:    revision.ChirpID,
+			Body:       revision.Body,
+			CreatedAt:  revision.CreatedAt,
+			ReplacedAt: revision.ReplacedAt,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, history)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/entities"
+	"GoServer/internal/pagination"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const maxFilterAuthors = 50
+
+// engagementWindow is the longest span of chirps sorted by engagement. Their
+// counts are computed on every request, so the chirps ranked are bounded.
+const engagementWindow = 7 * 24 * time.Hour
+
+// chirpFilters narrows a chirp listing. Unset fields do not filter; the
+// listing queries apply all of them in SQL.
+type chirpFilters struct {
+	AuthorIDs []uuid.UUID
+	Since     sql.NullTime
+	Until     sql.NullTime
+	HasMedia  sql.NullBool
+	IsReply   sql.NullBool
+	Tag       sql.NullString
+}
+
+// parseChirpFilters reads the filters of GET /api/chirps. author_id may be
+// repeated or hold a comma separated list.
+func parseChirpFilters(query url.Values) (chirpFilters, error) {
+	var filters chirpFilters
+
+	seen := map[uuid.UUID]bool{}
+	for _, value := range query["author_id"] {
+		for _, s := range strings.Split(value, ",") {
+			id, err := uuid.Parse(strings.TrimSpace(s))
+			if err != nil {
+				return chirpFilters{}, errors.New("Invalid author ID format")
+			}
+			if !seen[id] {
+				seen[id] = true
+				filters.AuthorIDs = append(filters.AuthorIDs, id)
+			}
+		}
+	}
+	if len(filters.AuthorIDs) > maxFilterAuthors {
+		return chirpFilters{}, fmt.Errorf("At most %d authors can be given", maxFilterAuthors)
+	}
+
+	var err error
+	if filters.Since, err = parseTimeFilter(query, "since"); err != nil {
+		return chirpFilters{}, err
+	}
+	if filters.Until, err = parseTimeFilter(query, "until"); err != nil {
+		return chirpFilters{}, err
+	}
+	if filters.Since.Valid && filters.Until.Valid && !filters.Since.Time.Before(filters.Until.Time) {
+		return chirpFilters{}, errors.New("since must be before until")
+	}
+
+	if filters.HasMedia, err = parseBoolFilter(query, "has_media"); err != nil {
+		return chirpFilters{}, err
+	}
+	if filters.IsReply, err = parseBoolFilter(query, "is_reply"); err != nil {
+		return chirpFilters{}, err
+	}
+
+	if query.Has("tag") {
+		tag := entities.NormalizeTag(query.Get("tag"))
+		if tag == "" {
+			return chirpFilters{}, errors.New("Invalid tag")
+		}
+		filters.Tag = sql.NullString{String: tag, Valid: true}
+	}
+	return filters, nil
+}
+
+func parseTimeFilter(query url.Values, name string) (sql.NullTime, error) {
+	s := query.Get(name)
+	if s == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
+
+func parseBoolFilter(query url.Values, name string) (sql.NullBool, error) {
+	s := query.Get(name)
+	if s == "" {
+		return sql.NullBool{}, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return sql.NullBool{}, fmt.Errorf("%s must be true or false", name)
+	}
+	return sql.NullBool{Bool: b, Valid: true}, nil
+}
+
+// getChirpsByEngagement lists chirps by their like, reply, rechirp and quote
+// count. Counts move between requests, so like search results the listing
+// is only paged forward. Only chirps within engagementWindow are ranked:
+// since defaults to that long before until, or before now.
+func (cfg *apiConfig) getChirpsByEngagement(w http.ResponseWriter, r *http.Request, viewerID uuid.UUID, page pagination.Params, filters chirpFilters) {
+	if page.Cursor != nil && page.Cursor.Backward {
+		respondWithError(w, http.StatusBadRequest, "Chirps sorted by engagement can only be paged forward", nil)
+		return
+	}
+	until := time.Now().UTC()
+	if filters.Until.Valid {
+		until = filters.Until.Time
+	}
+	since := until.Add(-engagementWindow)
+	if filters.Since.Valid {
+		if until.Sub(filters.Since.Time) > engagementWindow {
+			respondWithError(w, http.StatusBadRequest, "Chirps sorted by engagement must be from within 7 days", nil)
+			return
+		}
+		since = filters.Since.Time
+	}
+
+	params := database.ListChirpsByEngagementParams{
+		ViewerID:  viewerID,
+		AuthorIds: filters.AuthorIDs,
+		Since:     since,
+		Until:     filters.Until,
+		HasMedia:  filters.HasMedia,
+		IsReply:   filters.IsReply,
+		Tag:       filters.Tag,
+		RowLimit:  int32(page.Limit + 1),
+	}
+	if page.Cursor != nil {
+		params.CursorScore = sql.NullInt64{Int64: int64(page.Cursor.Score), Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.Cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.DB.ListChirpsByEngagement(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get all chirps", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		nextCursor = pagination.Cursor{ID: last.ID, Score: float64(last.Score)}.Encode()
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	chirps, err := cfg.getChirpsInOrder(r.Context(), viewerID, ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get all chirps", err)
+		return
+	}
+	chirpsResponse, err := cfg.buildChirps(r.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get all chirps", err)
+		return
+	}
+
+	pagination.SetLinkHeader(w, r, nextCursor, "")
+	respondWithJSON(w, http.StatusOK, pagination.Page[Chirp]{
+		Items:      chirpsResponse,
+		NextCursor: nextCursor,
+	})
+}
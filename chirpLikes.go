@@ -0,0 +1,133 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/pagination"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) likeChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return
+	}
+
+	chirp, err := cfg.DB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{ID: chirpID, ViewerID: userID})
+	if err == nil && (chirp.DeletedAt.Valid || chirp.PublishAt.Valid) {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+
+	if err := cfg.DB.LikeChirp(r.Context(), database.LikeChirpParams{UserID: userID, ChirpID: chirpID}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error liking chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) unlikeChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return
+	}
+
+	if err := cfg.DB.UnlikeChirp(r.Context(), database.UnlikeChirpParams{UserID: userID, ChirpID: chirpID}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error unliking chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) getUserLikes(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.optionalUserID(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID format", err)
+		return
+	}
+
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if page.Cursor != nil && page.Cursor.Backward {
+		respondWithError(w, http.StatusBadRequest, "Likes can only be paged forward", nil)
+		return
+	}
+
+	if _, err := cfg.DB.GetUserByID(r.Context(), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "User not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving user", err)
+		return
+	}
+
+	params := database.ListUserLikesParams{UserID: userID, RowLimit: int32(page.Limit + 1)}
+	if page.Cursor != nil {
+		params.CursorCreatedAt = sql.NullTime{Time: page.Cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.Cursor.ID, Valid: true}
+	}
+	likes, err := cfg.DB.ListUserLikes(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving likes", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(likes) > page.Limit {
+		likes = likes[:page.Limit]
+		last := likes[len(likes)-1]
+		nextCursor = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ChirpID}.Encode()
+	}
+
+	ids := make([]uuid.UUID, len(likes))
+	for i, like := range likes {
+		ids[i] = like.ChirpID
+	}
+	chirps, err := cfg.getChirpsInOrder(r.Context(), viewerID, ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving likes", err)
+		return
+	}
+	chirpsResponse, err := cfg.buildChirps(r.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving likes", err)
+		return
+	}
+
+	pagination.SetLinkHeader(w, r, nextCursor, "")
+	respondWithJSON(w, http.StatusOK, pagination.Page[Chirp]{
+		Items:      chirpsResponse,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// getOriginalChirp loads the chirp a rechirp or quote should point at. A
+// rechirp is resolved to the chirp it reposts, and tombstones and scheduled
+// chirps count as missing.
+func (cfg *apiConfig) getOriginalChirp(ctx context.Context, viewerID, chirpID uuid.UUID) (database.Chirp, error) {
+	chirp, err := cfg.DB.GetVisibleChirpByID(ctx, database.GetVisibleChirpByIDParams{ID: chirpID, ViewerID: viewerID})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if chirp.RechirpOfID.Valid {
+		chirp, err = cfg.DB.GetVisibleChirpByID(ctx, database.GetVisibleChirpByIDParams{ID: chirp.RechirpOfID.UUID, ViewerID: viewerID})
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	if chirp.DeletedAt.Valid || chirp.PublishAt.Valid {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return chirp, nil
+}
+
+func (cfg *apiConfig) rechirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return
+	}
+
+	original, err := cfg.getOriginalChirp(r.Context(), userID, chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+	if !sharable(original) {
+		respondWithError(w, http.StatusForbidden, "This chirp can't be shared", nil)
+		return
+	}
+
+	var chirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		chirp, err = q.CreateRechirp(r.Context(), database.CreateRechirpParams{
+			UserID:      userID,
+			RechirpOfID: uuid.NullUUID{UUID: original.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		return q.EnqueueFanout(r.Context(), chirp.ID)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusConflict, "Chirp already rechirped", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error creating rechirp", err)
+		return
+	}
+
+	chirpResponse, err := cfg.buildChirp(r.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, chirpResponse)
+}
+
+func (cfg *apiConfig) undoRechirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return
+	}
+
+	rechirp, err := cfg.DB.GetUserRechirp(r.Context(), database.GetUserRechirpParams{
+		UserID:      userID,
+		RechirpOfID: uuid.NullUUID{UUID: chirpID, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Rechirp not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving rechirp", err)
+		return
+	}
+
+	// only the rechirp row goes away, the original is never touched
+	if err := cfg.removeChirp(r.Context(), rechirp, userID, deletionByAuthor, authorDeletionReason); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Rechirp not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error deleting rechirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) quoteChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return
+	}
+
+	params := struct {
+		Body           string `json:"body"`
+		Visibility     string `json:"visibility"`
+		ContentWarning string `json:"content_warning"`
+		Sensitive      bool   `json:"sensitive"`
+	}{}
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.maxChirpRequestBytes())
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error unmarshalling Chirp", err)
+		return
+	}
+	visibility, err := parseVisibility(params.Visibility)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	warning, err := cfg.parseContentWarning(params.ContentWarning, params.Sensitive)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	limit, err := cfg.chirpLengthLimit(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving user", err)
+		return
+	}
+	outcome, err := cfg.checkChirpBody(params.Body, limit)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	outcome.Decisions = append(outcome.Decisions, warning.Decisions...)
+
+	original, err := cfg.getOriginalChirp(r.Context(), userID, chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+	if !sharable(original) {
+		respondWithError(w, http.StatusForbidden, "This chirp can't be shared", nil)
+		return
+	}
+
+	var chirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		chirp, err = cfg.insertChirp(r.Context(), q, database.CreateChirpParams{
+			Body:           outcome.Body,
+			UserID:         userID,
+			QuoteOfID:      uuid.NullUUID{UUID: original.ID, Valid: true},
+			Visibility:     visibility,
+			ContentWarning: warning.Text,
+			Sensitive:      warning.Sensitive,
+		}, outcome.Decisions)
+		return err
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating chirp", err)
+		return
+	}
+
+	chirpResponse, err := cfg.buildChirp(r.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+	chirpResponse.Moderation = outcome.Decisions
+	respondWithJSON(w, http.StatusCreated, chirpResponse)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/entities"
+	"GoServer/internal/markup"
+	"context"
+	"net/url"
+
+	"github.com/google/uuid"
+)
+
+// buildChirps converts database rows into API chirps, loading the aggregated
+// fields for the whole slice with one query per field rather than per chirp.
+// viewerID is uuid.Nil for anonymous callers.
+func (cfg *apiConfig) buildChirps(ctx context.Context, viewerID uuid.UUID, chirps []database.Chirp) ([]Chirp, error) {
+	response, err := cfg.hydrateChirps(ctx, viewerID, chirps)
+	if err != nil {
+		return nil, err
+	}
+
+	var originalIDs []uuid.UUID
+	for _, chirp := range chirps {
+		if chirp.RechirpOfID.Valid {
+			originalIDs = append(originalIDs, chirp.RechirpOfID.UUID)
+		}
+		if chirp.QuoteOfID.Valid {
+			originalIDs = append(originalIDs, chirp.QuoteOfID.UUID)
+		}
+	}
+	if len(originalIDs) == 0 {
+		return response, nil
+	}
+
+	// originals are embedded one level deep only
+	originals, err := cfg.getChirpsInOrder(ctx, viewerID, originalIDs)
+	if err != nil {
+		return nil, err
+	}
+	hydrated, err := cfg.hydrateChirps(ctx, viewerID, originals)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]Chirp, len(hydrated))
+	for _, original := range hydrated {
+		byID[original.ID] = original
+	}
+	embed := func(id uuid.NullUUID) *Chirp {
+		if !id.Valid {
+			return nil
+		}
+		if original, ok := byID[id.UUID]; ok {
+			return &original
+		}
+		return &Chirp{ID: id.UUID, Deleted: true}
+	}
+	for i, chirp := range chirps {
+		response[i].RechirpOf = embed(chirp.RechirpOfID)
+		response[i].QuoteOf = embed(chirp.QuoteOfID)
+	}
+	return response, nil
+}
+
+func (cfg *apiConfig) hydrateChirps(ctx context.Context, viewerID uuid.UUID, chirps []database.Chirp) ([]Chirp, error) {
+	ids := make([]uuid.UUID, len(chirps))
+	for i, chirp := range chirps {
+		ids[i] = chirp.ID
+	}
+
+	replyCounts := map[uuid.UUID]int64{}
+	likeStats := map[uuid.UUID]database.GetLikeStatsRow{}
+	rechirpStats := map[uuid.UUID]database.GetRechirpStatsRow{}
+	mentions := map[uuid.UUID]map[string]uuid.UUID{}
+	attachments := map[uuid.UUID][]ChirpMedia{}
+	polls := map[uuid.UUID]*Poll{}
+	bookmarked := map[uuid.UUID]bool{}
+	expandWarnings := false
+	if len(ids) > 0 {
+		rows, err := cfg.DB.GetReplyCounts(ctx, database.GetReplyCountsParams{ViewerID: viewerID, ChirpIds: ids})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			replyCounts[row.ChirpID] = row.ReplyCount
+		}
+
+		likes, err := cfg.DB.GetLikeStats(ctx, database.GetLikeStatsParams{ViewerID: viewerID, ChirpIds: ids})
+		if err != nil {
+			return nil, err
+		}
+		for _, like := range likes {
+			likeStats[like.ChirpID] = like
+		}
+
+		rechirps, err := cfg.DB.GetRechirpStats(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, rechirp := range rechirps {
+			rechirpStats[rechirp.ChirpID] = rechirp
+		}
+
+		mentionRows, err := cfg.DB.GetChirpMentions(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, mention := range mentionRows {
+			if mentions[mention.ChirpID] == nil {
+				mentions[mention.ChirpID] = map[string]uuid.UUID{}
+			}
+			mentions[mention.ChirpID][mention.Handle] = mention.UserID
+		}
+
+		mediaFiles, err := cfg.DB.GetMediaForChirps(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range mediaFiles {
+			attachments[file.ChirpID.UUID] = append(attachments[file.ChirpID.UUID], chirpMediaFromDB(file))
+		}
+
+		polls, err = cfg.getChirpPolls(ctx, viewerID, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		if viewerID != uuid.Nil {
+			bookmarkedIDs, err := cfg.DB.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{UserID: viewerID, ChirpIds: ids})
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range bookmarkedIDs {
+				bookmarked[id] = true
+			}
+		}
+
+		expandWarnings, err = cfg.expandsContentWarnings(ctx, viewerID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response := make([]Chirp, len(chirps))
+	for i, chirp := range chirps {
+		response[i] = chirpFromDB(chirp)
+		response[i].ReplyCount = replyCounts[chirp.ID]
+		response[i].LikeCount = likeStats[chirp.ID].LikeCount
+		response[i].LikedByMe = likeStats[chirp.ID].LikedByViewer
+		response[i].Bookmarked = bookmarked[chirp.ID]
+		response[i].RechirpCount = rechirpStats[chirp.ID].RechirpCount
+		response[i].QuoteCount = rechirpStats[chirp.ID].QuoteCount
+		response[i].Entities, response[i].BodyHTML = renderChirpBody(response[i].Body, mentions[chirp.ID])
+		response[i].Collapsed = response[i].ContentWarning != "" && !expandWarnings
+		response[i].Media = attachments[chirp.ID]
+		if response[i].Media == nil || response[i].Deleted {
+			response[i].Media = []ChirpMedia{}
+		}
+		if !response[i].Deleted {
+			response[i].Poll = polls[chirp.ID]
+		}
+	}
+	return response, nil
+}
+
+func (cfg *apiConfig) buildChirp(ctx context.Context, viewerID uuid.UUID, chirp database.Chirp) (Chirp, error) {
+	chirps, err := cfg.buildChirps(ctx, viewerID, []database.Chirp{chirp})
+	if err != nil {
+		return Chirp{}, err
+	}
+	return chirps[0], nil
+}
+
+// getChirpsInOrder loads the given chirps and returns them in the order of
+// ids, skipping any that no longer exist or that viewerID may not see.
+func (cfg *apiConfig) getChirpsInOrder(ctx context.Context, viewerID uuid.UUID, ids []uuid.UUID) ([]database.Chirp, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := cfg.DB.GetChirpsByIDs(ctx, database.GetChirpsByIDsParams{Ids: ids, ViewerID: viewerID})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]database.Chirp, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+	chirps := make([]database.Chirp, 0, len(ids))
+	for _, id := range ids {
+		if chirp, ok := byID[id]; ok {
+			chirps = append(chirps, chirp)
+		}
+	}
+	return chirps, nil
+}
+
+// renderChirpBody parses the markup of body into its entities and HTML.
+// resolved maps the normalized handles mentioned in the chirp to user IDs;
+// other mentions are left out of the entities and rendered as text.
+func renderChirpBody(body string, resolved map[string]uuid.UUID) ([]entities.Entity, string) {
+	doc := markup.Parse(body)
+	found := []entities.Entity{}
+	for _, entity := range doc.Entities() {
+		if entity.Type == entities.TypeMention {
+			userID, ok := resolved[entity.Value]
+			if !ok {
+				continue
+			}
+			entity.UserID = &userID
+		}
+		found = append(found, entity)
+	}
+	bodyHTML := doc.HTML(markup.Links{
+		Mention: func(handle string) (string, bool) {
+			userID, ok := resolved[handle]
+			return "/api/chirps?author_id=" + userID.String(), ok
+		},
+		Hashtag: func(tag string) string {
+			return "/api/tags/" + url.PathEscape(tag) + "/chirps"
+		},
+	})
+	return found, bodyHTML
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/pagination"
+	"GoServer/internal/search"
+	"database/sql"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type ChirpSearchResult struct {
+	Chirp
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+func (cfg *apiConfig) searchChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.optionalUserID(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	tsQuery, err := search.ParseQuery(query.Get("q"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	page, err := pagination.ParseParams(query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if page.Cursor != nil && page.Cursor.Backward {
+		respondWithError(w, http.StatusBadRequest, "Search results can only be paged forward", nil)
+		return
+	}
+
+	authorID := uuid.NullUUID{}
+	if s := query.Get("author_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid author ID format", err)
+			return
+		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	params := database.SearchChirpsParams{
+		Query:    tsQuery,
+		ViewerID: viewerID,
+		AuthorID: authorID,
+		RowLimit: int32(page.Limit + 1),
+	}
+	if page.Cursor != nil {
+		params.CursorRank = sql.NullFloat64{Float64: page.Cursor.Score, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.Cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.DB.SearchChirps(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't search chirps", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		nextCursor = pagination.Cursor{ID: last.ID, Score: last.Rank}.Encode()
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	chirps, err := cfg.getChirpsInOrder(r.Context(), viewerID, ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't search chirps", err)
+		return
+	}
+	chirpsResponse, err := cfg.buildChirps(r.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't search chirps", err)
+		return
+	}
+
+	matches := make(map[uuid.UUID]database.SearchChirpsRow, len(rows))
+	for _, row := range rows {
+		matches[row.ID] = row
+	}
+	results := make([]ChirpSearchResult, len(chirpsResponse))
+	for i, chirp := range chirpsResponse {
+		results[i] = ChirpSearchResult{
+			Chirp:   chirp,
+			Rank:    matches[chirp.ID].Rank,
+			Snippet: matches[chirp.ID].Snippet,
+		}
+	}
+
+	pagination.SetLinkHeader(w, r, nextCursor, "")
+	respondWithJSON(w, http.StatusOK, pagination.Page[ChirpSearchResult]{
+		Items:      results,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/pagination"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultThreadDepth = 10
+	maxThreadDepth     = 50
+)
+
+type ThreadReply struct {
+	Chirp
+	Depth int32 `json:"depth"`
+}
+
+type ChirpThread struct {
+	Ancestors   []Chirp                      `json:"ancestors"`
+	Chirp       Chirp                        `json:"chirp"`
+	Descendants pagination.Page[ThreadReply] `json:"descendants"`
+}
+
+func (cfg *apiConfig) createReply(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	parentID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return
+	}
+
+	params := struct {
+		Body           string `json:"body"`
+		Visibility     string `json:"visibility"`
+		ContentWarning string `json:"content_warning"`
+		Sensitive      bool   `json:"sensitive"`
+	}{}
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.maxChirpRequestBytes())
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error unmarshalling Chirp", err)
+		return
+	}
+	visibility, err := parseVisibility(params.Visibility)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	warning, err := cfg.parseContentWarning(params.ContentWarning, params.Sensitive)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	limit, err := cfg.chirpLengthLimit(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving user", err)
+		return
+	}
+	outcome, err := cfg.checkChirpBody(params.Body, limit)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	outcome.Decisions = append(outcome.Decisions, warning.Decisions...)
+
+	parent, err := cfg.DB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{ID: parentID, ViewerID: userID})
+	if err == nil && parent.PublishAt.Valid {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+	if parent.DeletedAt.Valid {
+		respondWithError(w, http.StatusConflict, "Cannot reply to a deleted chirp", nil)
+		return
+	}
+
+	rootID := parent.RootID
+	if !rootID.Valid {
+		rootID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
+	var chirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		chirp, err = cfg.insertChirp(r.Context(), q, database.CreateChirpParams{
+			Body:           outcome.Body,
+			UserID:         userID,
+			ParentID:       uuid.NullUUID{UUID: parent.ID, Valid: true},
+			RootID:         rootID,
+			Visibility:     visibility,
+			ContentWarning: warning.Text,
+			Sensitive:      warning.Sensitive,
+		}, outcome.Decisions)
+		return err
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating chirp", err)
+		return
+	}
+
+	chirpResponse, err := cfg.buildChirp(r.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+	chirpResponse.Moderation = outcome.Decisions
+	respondWithJSON(w, http.StatusCreated, chirpResponse)
+}
+
+func (cfg *apiConfig) getChirpThread(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.optionalUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return
+	}
+
+	query := r.URL.Query()
+	page, err := pagination.ParseParams(query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if page.Cursor != nil && (page.Cursor.Backward || page.Cursor.Key == "") {
+		respondWithError(w, http.StatusBadRequest, "invalid cursor", nil)
+		return
+	}
+
+	depth := defaultThreadDepth
+	if s := query.Get("depth"); s != "" {
+		depth, err = strconv.Atoi(s)
+		if err != nil || depth < 1 || depth > maxThreadDepth {
+			respondWithError(w, http.StatusBadRequest, "depth must be between 1 and "+strconv.Itoa(maxThreadDepth), nil)
+			return
+		}
+	}
+
+	chirp, err := cfg.DB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{ID: chirpID, ViewerID: viewerID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+
+	ancestorIDs, err := cfg.DB.GetChirpAncestorIDs(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving thread", err)
+		return
+	}
+	ancestors, err := cfg.getChirpsInOrder(r.Context(), viewerID, ancestorIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving thread", err)
+		return
+	}
+
+	cursorPath := sql.NullString{}
+	if page.Cursor != nil {
+		cursorPath = sql.NullString{String: page.Cursor.Key, Valid: true}
+	}
+	descendants, err := cfg.DB.GetChirpDescendants(r.Context(), database.GetChirpDescendantsParams{
+		ChirpID:    chirpID,
+		MaxDepth:   int32(depth),
+		CursorPath: cursorPath,
+		RowLimit:   int32(page.Limit + 1),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving thread", err)
+		return
+	}
+	nextCursor := ""
+	if len(descendants) > page.Limit {
+		descendants = descendants[:page.Limit]
+		last := descendants[len(descendants)-1]
+		nextCursor = pagination.Cursor{ID: last.ID, Key: last.Path}.Encode()
+	}
+
+	descendantIDs := make([]uuid.UUID, len(descendants))
+	depths := make(map[uuid.UUID]int32, len(descendants))
+	for i, descendant := range descendants {
+		descendantIDs[i] = descendant.ID
+		depths[descendant.ID] = descendant.Depth
+	}
+	replies, err := cfg.getChirpsInOrder(r.Context(), viewerID, descendantIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving thread", err)
+		return
+	}
+
+	// one pass over every chirp in the thread keeps the aggregates to a
+	// single query each
+	all := append(append(ancestors, chirp), replies...)
+	built, err := cfg.buildChirps(r.Context(), viewerID, all)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving thread", err)
+		return
+	}
+
+	thread := ChirpThread{
+		Ancestors: built[:len(ancestors)],
+		Chirp:     built[len(ancestors)],
+		Descendants: pagination.Page[ThreadReply]{
+			Items:      make([]ThreadReply, 0, len(replies)),
+			NextCursor: nextCursor,
+		},
+	}
+	for _, reply := range built[len(ancestors)+1:] {
+		thread.Descendants.Items = append(thread.Descendants.Items, ThreadReply{Chirp: reply, Depth: depths[reply.ID]})
+	}
+
+	pagination.SetLinkHeader(w, r, nextCursor, "")
+	respondWithJSON(w, http.StatusOK, thread)
+}
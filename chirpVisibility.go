@@ -0,0 +1,35 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"errors"
+)
+
+// Who may read a chirp. The rules themselves live in the chirp_visible SQL
+// function, which every read query applies for the viewer.
+const (
+	visibilityPublic = "public"
+	// visibilityUnlisted chirps can be read by anyone with the link but are
+	// left out of listings, search and feeds.
+	visibilityUnlisted  = "unlisted"
+	visibilityFollowers = "followers"
+	visibilityMentioned = "mentioned"
+)
+
+// parseVisibility validates the visibility of a new chirp, defaulting to
+// public.
+func parseVisibility(visibility string) (string, error) {
+	switch visibility {
+	case "":
+		return visibilityPublic, nil
+	case visibilityPublic, visibilityUnlisted, visibilityFollowers, visibilityMentioned:
+		return visibility, nil
+	}
+	return "", errors.New("visibility must be one of public, unlisted, followers or mentioned")
+}
+
+// sharable reports whether chirp may be rechirped or quoted, which would
+// show it to people its author did not choose.
+func sharable(chirp database.Chirp) bool {
+	return chirp.Visibility == visibilityPublic || chirp.Visibility == visibilityUnlisted
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/moderation"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// maxContentWarning is the longest content warning, in characters.
+const maxContentWarning = 100
+
+// contentWarning is a validated content warning.
+type contentWarning struct {
+	Text      sql.NullString
+	Sensitive bool
+	// Decisions are the moderation rules that fired on Text, which is
+	// already masked.
+	Decisions []moderation.Decision
+}
+
+// parseContentWarning validates the content warning of a chirp and runs it
+// through the moderation pipeline like a body. A chirp behind a warning is
+// always sensitive, so clients blur its media too.
+func (cfg *apiConfig) parseContentWarning(warning string, sensitive bool) (contentWarning, error) {
+	warning = strings.TrimSpace(warning)
+	if warning == "" {
+		return contentWarning{Sensitive: sensitive}, nil
+	}
+	outcome := cfg.Moderation.Run(warning)
+	if outcome.Rejected != nil {
+		return contentWarning{}, fmt.Errorf("Content warning %w rule %q", errRejectedByModeration, outcome.Rejected.Rule)
+	}
+	// masking can lengthen the text, so the stored text is what is checked
+	if utf8.RuneCountInString(outcome.Body) > maxContentWarning {
+		return contentWarning{}, fmt.Errorf("content_warning can be at most %d characters", maxContentWarning)
+	}
+	return contentWarning{
+		Text:      sql.NullString{String: outcome.Body, Valid: true},
+		Sensitive: true,
+		Decisions: outcome.Decisions,
+	}, nil
+}
+
+// expandsContentWarnings reports whether viewerID has chosen to see chirps
+// behind content warnings without expanding them first.
+func (cfg *apiConfig) expandsContentWarnings(ctx context.Context, viewerID uuid.UUID) (bool, error) {
+	if viewerID == uuid.Nil {
+		return false, nil
+	}
+	user, err := cfg.DB.GetUserByID(ctx, viewerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return user.ExpandContentWarnings, err
+}
+
+// setContentWarning lets moderators put a content warning on another user's
+// chirp, or change or lift one. An empty warning with sensitive false lifts
+// it. Until a moderator lifts it, the author cannot change the warning.
+func (cfg *apiConfig) setContentWarning(w http.ResponseWriter, r *http.Request) {
+	moderatorID, ok := cfg.requireRole(w, r, roleModerator)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return
+	}
+
+	params := struct {
+		ContentWarning string `json:"content_warning"`
+		Sensitive      bool   `json:"sensitive"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error unmarshalling content warning", err)
+		return
+	}
+	// the moderator is the reviewer, so flags on the warning are dropped
+	warning, err := cfg.parseContentWarning(params.ContentWarning, params.Sensitive)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	chirp, err := cfg.DB.SetChirpContentWarning(r.Context(), database.SetChirpContentWarningParams{
+		ContentWarning: warning.Text,
+		Sensitive:      warning.Sensitive,
+		ID:             chirpID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error updating chirp", err)
+		return
+	}
+
+	chirpResponse, err := cfg.buildChirp(r.Context(), moderatorID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, chirpResponse)
+}
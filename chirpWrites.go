@@ -0,0 +1,170 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/entities"
+	"GoServer/internal/markup"
+	"GoServer/internal/moderation"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// withTx runs fn against a transaction, committing only if fn succeeds.
+func (cfg *apiConfig) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := cfg.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(cfg.DB.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// chirpLengthLimit is the longest chirp userID may write, which depends on
+// their account tier.
+func (cfg *apiConfig) chirpLengthLimit(ctx context.Context, userID uuid.UUID) (int, error) {
+	user, err := cfg.DB.GetUserByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if user.IsChirpyRed {
+		return cfg.ChirpMaxLengthRed, nil
+	}
+	return cfg.ChirpMaxLength, nil
+}
+
+// errRejectedByModeration is wrapped by the errors of text a moderation rule
+// rejected.
+var errRejectedByModeration = errors.New("rejected by moderation")
+
+// checkChirpBody validates a chirp body against the author's length limit
+// and runs it through the moderation pipeline. The outcome's Body is what
+// should be stored; since masking can lengthen it, it is checked again.
+func (cfg *apiConfig) checkChirpBody(body string, limit int) (moderation.Outcome, error) {
+	if err := validateChirpBody(body, limit); err != nil {
+		return moderation.Outcome{}, err
+	}
+	outcome := cfg.Moderation.Run(body)
+	if outcome.Rejected != nil {
+		return outcome, fmt.Errorf("Chirp %w rule %q", errRejectedByModeration, outcome.Rejected.Rule)
+	}
+	if err := validateChirpBody(outcome.Body, limit); err != nil {
+		return outcome, errors.New("Chirp is too long once moderated words are masked")
+	}
+	return outcome, nil
+}
+
+// insertChirp creates a chirp together with everything derived from its
+// body, recording the moderation decisions that flagged it for review. q
+// should be bound to a transaction.
+func (cfg *apiConfig) insertChirp(ctx context.Context, q *database.Queries, params database.CreateChirpParams, decisions []moderation.Decision) (database.Chirp, error) {
+	if params.Visibility == "" {
+		params.Visibility = visibilityPublic
+	}
+	chirp, err := q.CreateChirp(ctx, params)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if err := cfg.indexChirpEntities(ctx, q, chirp); err != nil {
+		return database.Chirp{}, err
+	}
+	if err := recordModerationFlags(ctx, q, chirp.ID, decisions); err != nil {
+		return database.Chirp{}, err
+	}
+	if !chirp.PublishAt.Valid {
+		// scheduled chirps are queued by the publisher
+		if err := q.EnqueueFanout(ctx, chirp.ID); err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	return chirp, nil
+}
+
+func recordModerationFlags(ctx context.Context, q *database.Queries, chirpID uuid.UUID, decisions []moderation.Decision) error {
+	for _, decision := range decisions {
+		if decision.Action != moderation.ActionFlag {
+			continue
+		}
+		if err := q.CreateModerationFlag(ctx, database.CreateModerationFlagParams{ChirpID: chirpID, Rule: decision.Rule}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexChirpEntities replaces the stored hashtags and mentions of chirp with
+// the ones in its current body. Mentions of handles nobody owns are left as
+// plain text, and tags or handles inside code spans and links do not count.
+func (cfg *apiConfig) indexChirpEntities(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if chirp.PublishAt.Valid {
+		// scheduled chirps are indexed by the publisher
+		return nil
+	}
+	if err := indexChirpTags(ctx, q, chirp); err != nil {
+		return err
+	}
+	return indexChirpMentions(ctx, q, chirp)
+}
+
+func indexChirpTags(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if err := q.DeleteChirpTags(ctx, chirp.ID); err != nil {
+		return err
+	}
+	found := markup.Parse(chirp.Body).Entities()
+	for _, name := range entities.UniqueValues(entities.OfType(found, entities.TypeHashtag)) {
+		tag, err := q.UpsertTag(ctx, name)
+		if err != nil {
+			return err
+		}
+		if err := q.AddChirpTag(ctx, database.AddChirpTagParams{
+			ChirpID:   chirp.ID,
+			TagID:     tag.ID,
+			CreatedAt: chirp.CreatedAt,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func indexChirpMentions(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if err := q.DeleteChirpMentions(ctx, chirp.ID); err != nil {
+		return err
+	}
+	found := markup.Parse(chirp.Body).Entities()
+	handles := entities.UniqueValues(entities.OfType(found, entities.TypeMention))
+	if len(handles) == 0 {
+		return nil
+	}
+	users, err := q.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if err := q.AddMention(ctx, database.AddMentionParams{
+			ChirpID:   chirp.ID,
+			UserID:    user.ID,
+			Handle:    entities.NormalizeHandle(user.Handle.String),
+			CreatedAt: chirp.CreatedAt,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
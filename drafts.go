@@ -0,0 +1,226 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/moderation"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Draft is a chirp being composed. Its body and content warning are only
+// validated when it is published.
+type Draft struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Body           string    `json:"body"`
+	ContentWarning string    `json:"content_warning,omitempty"`
+	Sensitive      bool      `json:"sensitive"`
+	UserID         uuid.UUID `json:"user_id"`
+}
+
+func draftFromDB(draft database.Draft) Draft {
+	return Draft{
+		ID:             draft.ID,
+		CreatedAt:      draft.CreatedAt,
+		UpdatedAt:      draft.UpdatedAt,
+		Body:           draft.Body,
+		ContentWarning: draft.ContentWarning.String,
+		Sensitive:      draft.Sensitive,
+		UserID:         draft.UserID,
+	}
+}
+
+// draftParams is the body of the requests that save a draft.
+type draftParams struct {
+	Body           string `json:"body"`
+	ContentWarning string `json:"content_warning"`
+	Sensitive      bool   `json:"sensitive"`
+}
+
+func (p draftParams) contentWarning() sql.NullString {
+	return sql.NullString{String: p.ContentWarning, Valid: p.ContentWarning != ""}
+}
+
+func (cfg *apiConfig) createDraft(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	params := draftParams{}
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.maxChirpRequestBytes())
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error unmarshalling draft", err)
+		return
+	}
+
+	draft, err := cfg.DB.CreateDraft(r.Context(), database.CreateDraftParams{
+		UserID:         userID,
+		Body:           params.Body,
+		ContentWarning: params.contentWarning(),
+		Sensitive:      params.Sensitive,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating draft", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, draftFromDB(draft))
+}
+
+func (cfg *apiConfig) getDrafts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	drafts, err := cfg.DB.ListDrafts(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving drafts", err)
+		return
+	}
+	response := make([]Draft, len(drafts))
+	for i, draft := range drafts {
+		response[i] = draftFromDB(draft)
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) updateDraft(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID format", err)
+		return
+	}
+
+	params := draftParams{}
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.maxChirpRequestBytes())
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error unmarshalling draft", err)
+		return
+	}
+
+	draft, err := cfg.DB.UpdateDraft(r.Context(), database.UpdateDraftParams{
+		Body:           params.Body,
+		ContentWarning: params.contentWarning(),
+		Sensitive:      params.Sensitive,
+		ID:             draftID,
+		UserID:         userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Draft not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error updating draft", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, draftFromDB(draft))
+}
+
+func (cfg *apiConfig) deleteDraft(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID format", err)
+		return
+	}
+
+	deleted, err := cfg.DB.DeleteDraft(r.Context(), database.DeleteDraftParams{ID: draftID, UserID: userID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting draft", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Draft not found", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// publishDraft turns a draft into a chirp. The draft row stays locked until
+// the chirp is created and the draft deleted, so publishing twice at once
+// creates a single chirp.
+func (cfg *apiConfig) publishDraft(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID format", err)
+		return
+	}
+
+	limit, err := cfg.chirpLengthLimit(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving user", err)
+		return
+	}
+
+	var chirp database.Chirp
+	var outcome moderation.Outcome
+	var bodyErr error
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		draft, err := q.GetDraftForUpdate(r.Context(), database.GetDraftForUpdateParams{ID: draftID, UserID: userID})
+		if err != nil {
+			return err
+		}
+		warning, err := cfg.parseContentWarning(draft.ContentWarning.String, draft.Sensitive)
+		if err != nil {
+			bodyErr = err
+			return bodyErr
+		}
+		outcome, bodyErr = cfg.checkChirpBody(draft.Body, limit)
+		if bodyErr != nil {
+			return bodyErr
+		}
+		outcome.Decisions = append(outcome.Decisions, warning.Decisions...)
+		chirp, err = cfg.insertChirp(r.Context(), q, database.CreateChirpParams{
+			Body:           outcome.Body,
+			UserID:         userID,
+			ContentWarning: warning.Text,
+			Sensitive:      warning.Sensitive,
+		}, outcome.Decisions)
+		if err != nil {
+			return err
+		}
+		_, err = q.DeleteDraft(r.Context(), database.DeleteDraftParams{ID: draftID, UserID: userID})
+		return err
+	})
+	if bodyErr != nil {
+		respondWithError(w, http.StatusBadRequest, bodyErr.Error(), nil)
+		return
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Draft not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error publishing draft", err)
+		return
+	}
+
+	chirpResponse, err := cfg.buildChirp(r.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+	chirpResponse.Moderation = outcome.Decisions
+	respondWithJSON(w, http.StatusCreated, chirpResponse)
+}
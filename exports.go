@@ -0,0 +1,366 @@
+package main
+
+import (
+	"GoServer/internal/archive"
+	"GoServer/internal/auth"
+	"GoServer/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// exportRetention is how long a finished archive can be downloaded.
+	exportRetention = 7 * 24 * time.Hour
+	// exportLinkTTL bounds the lifetime of each download link handed out.
+	exportLinkTTL = time.Hour
+	// exportStaleAfter is when a running export is assumed to have died with
+	// its server and is picked up again.
+	exportStaleAfter = time.Hour
+)
+
+type ExportJob struct {
+	ID          uuid.UUID  `json:"id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	SizeBytes   int64      `json:"size_bytes,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	// DownloadURL is a signed link that works without authentication until
+	// ExpiresAt.
+	DownloadURL string     `json:"download_url,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+func (cfg *apiConfig) exportJobFromDB(job database.ExportJob) ExportJob {
+	response := ExportJob{
+		ID:        job.ID,
+		Status:    job.Status,
+		CreatedAt: job.CreatedAt,
+		SizeBytes: job.SizeBytes.Int64,
+		Error:     job.Error.String,
+	}
+	if job.CompletedAt.Valid {
+		response.CompletedAt = &job.CompletedAt.Time
+	}
+	if job.Status == "done" {
+		expiresAt := time.Now().Add(exportLinkTTL)
+		if deadline := job.CompletedAt.Time.Add(exportRetention); deadline.Before(expiresAt) {
+			expiresAt = deadline
+		}
+		response.DownloadURL = auth.SignURL(exportDownloadPath(job.ID), cfg.Secret, expiresAt)
+		response.ExpiresAt = &expiresAt
+	}
+	return response
+}
+
+func exportDownloadPath(id uuid.UUID) string {
+	return "/api/exports/" + id.String() + "/download"
+}
+
+func (cfg *apiConfig) createExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := cfg.DB.CreateExportJob(r.Context(), userID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "An export is already in progress", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error starting export", err)
+		return
+	}
+	w.Header().Set("Location", "/api/users/me/exports/"+job.ID.String())
+	respondWithJSON(w, http.StatusAccepted, cfg.exportJobFromDB(job))
+}
+
+func (cfg *apiConfig) getExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	exportID, err := uuid.Parse(r.PathValue("exportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid export ID format", err)
+		return
+	}
+
+	job, err := cfg.DB.GetExportJob(r.Context(), database.GetExportJobParams{ID: exportID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Export not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving export", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, cfg.exportJobFromDB(job))
+}
+
+// downloadExport serves a finished archive to whoever holds a signed link,
+// so it can be fetched by a browser or download manager.
+func (cfg *apiConfig) downloadExport(w http.ResponseWriter, r *http.Request) {
+	exportID, err := uuid.Parse(r.PathValue("exportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid export ID format", err)
+		return
+	}
+	if err := auth.VerifySignedURL(r.URL, cfg.Secret, time.Now()); err != nil {
+		respondWithError(w, http.StatusForbidden, err.Error(), nil)
+		return
+	}
+
+	job, err := cfg.DB.GetFinishedExportJob(r.Context(), exportID)
+	if err == nil && time.Since(job.CompletedAt.Time) > exportRetention {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Export not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving export", err)
+		return
+	}
+
+	blob, err := cfg.Blobs.Get(r.Context(), job.StorageKey.String)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error reading export", err)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="chirpy-%s.zip"`, job.CompletedAt.Time.Format("2006-01-02")))
+	w.Header().Set("Content-Length", strconv.FormatInt(job.SizeBytes.Int64, 10))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, blob); err != nil {
+		log.Printf("Error writing export %s: %s", job.ID, err)
+	}
+}
+
+// runExporter builds requested archives every interval until ctx is done,
+// and deletes the ones past exportRetention. Jobs are claimed with SKIP
+// LOCKED, so several servers can run it side by side.
+func (cfg *apiConfig) runExporter(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := cfg.runExportJobs(ctx); err != nil {
+				log.Printf("Error running exports: %s", err)
+			}
+			if err := cfg.deleteExpiredExports(ctx); err != nil {
+				log.Printf("Error deleting expired exports: %s", err)
+			}
+		}
+	}
+}
+
+func (cfg *apiConfig) runExportJobs(ctx context.Context) error {
+	for {
+		job, err := cfg.DB.ClaimExportJob(ctx, exportStaleAfter.Seconds())
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		key := "exports/" + job.ID.String() + ".zip"
+		size, err := cfg.buildExport(ctx, job.UserID, key)
+		if err != nil {
+			log.Printf("Error building export %s: %s", job.ID, err)
+			cfg.deleteBlobs(key)
+			err = cfg.DB.FailExportJob(ctx, database.FailExportJobParams{
+				Error: sql.NullString{String: "The archive could not be built", Valid: true},
+				ID:    job.ID,
+			})
+		} else {
+			err = cfg.DB.CompleteExportJob(ctx, database.CompleteExportJobParams{
+				StorageKey: sql.NullString{String: key, Valid: true},
+				SizeBytes:  sql.NullInt64{Int64: size, Valid: true},
+				ID:         job.ID,
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (cfg *apiConfig) deleteExpiredExports(ctx context.Context) error {
+	keys, err := cfg.DB.DeleteExpiredExportJobs(ctx, exportRetention.Seconds())
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if key.Valid {
+			cfg.deleteBlobs(key.String)
+		}
+	}
+	return nil
+}
+
+// buildExport streams the archive of userID into the blob store under key
+// and returns its size.
+func (cfg *apiConfig) buildExport(ctx context.Context, userID uuid.UUID, key string) (int64, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(cfg.writeExport(ctx, pw, userID))
+	}()
+	counter := &countingReader{r: pr}
+	err := cfg.Blobs.Put(ctx, key, counter)
+	// unblocks writeExport if the store gave up early
+	pr.CloseWithError(errors.New("export aborted"))
+	return counter.n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (cfg *apiConfig) writeExport(ctx context.Context, w io.Writer, userID uuid.UUID) error {
+	user, err := cfg.DB.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	chirps, err := cfg.DB.ListChirpsForExport(ctx, userID)
+	if err != nil {
+		return err
+	}
+	likes, err := cfg.DB.ListLikesForExport(ctx, userID)
+	if err != nil {
+		return err
+	}
+	followers, err := cfg.DB.ListFollowersForExport(ctx, userID)
+	if err != nil {
+		return err
+	}
+	ids := make([]uuid.UUID, len(chirps))
+	for i, chirp := range chirps {
+		ids[i] = chirp.ID
+	}
+	mediaFiles, err := cfg.DB.GetMediaForChirps(ctx, ids)
+	if err != nil {
+		return err
+	}
+	mediaByChirp := map[uuid.UUID][]database.MediaFile{}
+	for _, file := range mediaFiles {
+		mediaByChirp[file.ChirpID.UUID] = append(mediaByChirp[file.ChirpID.UUID], file)
+	}
+
+	aw, err := archive.NewWriter(w, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := aw.WriteProfile(archive.Profile{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		Email:       user.Email,
+		Handle:      user.Handle.String,
+		IsChirpyRed: user.IsChirpyRed,
+	}); err != nil {
+		return err
+	}
+
+	records := make([]archive.Chirp, len(chirps))
+	for i, chirp := range chirps {
+		records[i] = archiveChirp(chirp, mediaByChirp[chirp.ID])
+	}
+	if err := aw.WriteChirps(records); err != nil {
+		return err
+	}
+
+	likeRecords := make([]archive.Like, len(likes))
+	for i, like := range likes {
+		likeRecords[i] = archive.Like{ChirpID: like.ChirpID, CreatedAt: like.CreatedAt}
+	}
+	if err := aw.WriteLikes(likeRecords); err != nil {
+		return err
+	}
+
+	followerRecords := make([]archive.Follower, len(followers))
+	for i, follower := range followers {
+		followerRecords[i] = archive.Follower{UserID: follower.FollowerID, Handle: follower.Handle.String, CreatedAt: follower.CreatedAt}
+	}
+	if err := aw.WriteFollowers(followerRecords); err != nil {
+		return err
+	}
+
+	for _, file := range mediaFiles {
+		if err := cfg.writeExportMedia(ctx, aw, file); err != nil {
+			return err
+		}
+	}
+	return aw.Close()
+}
+
+func (cfg *apiConfig) writeExportMedia(ctx context.Context, aw *archive.Writer, file database.MediaFile) error {
+	blob, err := cfg.Blobs.Get(ctx, file.StorageKey)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+	return aw.WriteMedia(exportMediaName(file), blob)
+}
+
+func archiveChirp(chirp database.Chirp, files []database.MediaFile) archive.Chirp {
+	record := archive.Chirp{
+		ID:             chirp.ID,
+		CreatedAt:      chirp.CreatedAt,
+		UpdatedAt:      chirp.UpdatedAt,
+		Body:           chirp.Body,
+		Visibility:     chirp.Visibility,
+		ContentWarning: chirp.ContentWarning.String,
+		Sensitive:      chirp.Sensitive,
+	}
+	if chirp.ParentID.Valid {
+		record.ParentID = &chirp.ParentID.UUID
+	}
+	if chirp.QuoteOfID.Valid {
+		record.QuoteOfID = &chirp.QuoteOfID.UUID
+	}
+	if chirp.RechirpOfID.Valid {
+		record.RechirpOfID = &chirp.RechirpOfID.UUID
+	}
+	if chirp.PublishAt.Valid {
+		record.PublishAt = &chirp.PublishAt.Time
+	}
+	for _, file := range files {
+		record.Media = append(record.Media, archive.Media{
+			File:        archive.MediaFile(exportMediaName(file)),
+			ContentType: file.ContentType,
+			AltText:     file.AltText,
+		})
+	}
+	return record
+}
+
+func exportMediaName(file database.MediaFile) string {
+	ext := map[string]string{"image/jpeg": ".jpg", "image/png": ".png", "image/gif": ".gif"}[file.ContentType]
+	return file.ID.String() + ext
+}
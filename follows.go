@@ -0,0 +1,211 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/pagination"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Profile struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Handle         string    `json:"handle,omitempty"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+	FollowedByMe   bool      `json:"followed_by_me"`
+}
+
+// Follow is one side of a follow: the follower in a followers list, the
+// followed account in a following list.
+type Follow struct {
+	UserID     uuid.UUID `json:"user_id"`
+	Handle     string    `json:"handle,omitempty"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// getUserProfile returns the public profile of a user with their follow
+// counts.
+func (cfg *apiConfig) getUserProfile(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.optionalUserID(w, r)
+	if !ok {
+		return
+	}
+	user, ok := cfg.userFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := cfg.DB.GetFollowStats(r.Context(), database.GetFollowStatsParams{UserID: user.ID, ViewerID: viewerID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving user", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, Profile{
+		ID:             user.ID,
+		CreatedAt:      user.CreatedAt,
+		Handle:         user.Handle.String,
+		FollowerCount:  stats.FollowerCount,
+		FollowingCount: stats.FollowingCount,
+		FollowedByMe:   stats.FollowedByViewer,
+	})
+}
+
+// userFromPath loads the user named by the userID path value, responding
+// with an error if there is none.
+func (cfg *apiConfig) userFromPath(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID format", err)
+		return database.User{}, false
+	}
+	user, err := cfg.DB.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "User not found", nil)
+			return database.User{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving user", err)
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// followUser makes the caller follow a user and backfills their timeline with
+// the user's latest chirps. Following someone already followed is a no-op.
+func (cfg *apiConfig) followUser(w http.ResponseWriter, r *http.Request) {
+	followerID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	user, ok := cfg.userFromPath(w, r)
+	if !ok {
+		return
+	}
+	if user.ID == followerID {
+		respondWithError(w, http.StatusBadRequest, "You cannot follow yourself", nil)
+		return
+	}
+
+	err := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		followed, err := q.FollowUser(r.Context(), database.FollowUserParams{FollowerID: followerID, FolloweeID: user.ID})
+		if err != nil || followed == 0 {
+			return err
+		}
+		return cfg.backfillTimeline(r.Context(), q, followerID, user.ID)
+	})
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			respondWithError(w, http.StatusNotFound, "User not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error following user", err)
+		return
+	}
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// unfollowUser makes the caller stop following a user, dropping the user's
+// chirps from their stored timeline.
+func (cfg *apiConfig) unfollowUser(w http.ResponseWriter, r *http.Request) {
+	followerID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID format", err)
+		return
+	}
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		if _, err := q.UnfollowUser(r.Context(), database.UnfollowUserParams{FollowerID: followerID, FolloweeID: followeeID}); err != nil {
+			return err
+		}
+		return q.RemoveTimelineAuthor(r.Context(), database.RemoveTimelineAuthorParams{UserID: followerID, AuthorID: followeeID})
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error unfollowing user", err)
+		return
+	}
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// getFollowers lists who follows a user, most recent first.
+func (cfg *apiConfig) getFollowers(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, func(user database.User, page pagination.Params, cursorCreatedAt sql.NullTime, cursorID uuid.NullUUID) ([]Follow, error) {
+		rows, err := cfg.DB.ListFollowers(r.Context(), database.ListFollowersParams{
+			UserID:          user.ID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			RowLimit:        int32(page.Limit + 1),
+		})
+		follows := make([]Follow, len(rows))
+		for i, row := range rows {
+			follows[i] = Follow{UserID: row.ID, Handle: row.Handle.String, FollowedAt: row.CreatedAt}
+		}
+		return follows, err
+	})
+}
+
+// getFollowing lists who a user follows, most recent first.
+func (cfg *apiConfig) getFollowing(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, func(user database.User, page pagination.Params, cursorCreatedAt sql.NullTime, cursorID uuid.NullUUID) ([]Follow, error) {
+		rows, err := cfg.DB.ListFollowing(r.Context(), database.ListFollowingParams{
+			UserID:          user.ID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			RowLimit:        int32(page.Limit + 1),
+		})
+		follows := make([]Follow, len(rows))
+		for i, row := range rows {
+			follows[i] = Follow{UserID: row.ID, Handle: row.Handle.String, FollowedAt: row.CreatedAt}
+		}
+		return follows, err
+	})
+}
+
+// listFollows serves a page of followers or following. list fetches up to
+// page.Limit+1 follows after the cursor, newest first.
+func (cfg *apiConfig) listFollows(w http.ResponseWriter, r *http.Request, list func(user database.User, page pagination.Params, cursorCreatedAt sql.NullTime, cursorID uuid.NullUUID) ([]Follow, error)) {
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if page.Cursor != nil && page.Cursor.Backward {
+		respondWithError(w, http.StatusBadRequest, "Follows can only be paged forward", nil)
+		return
+	}
+	user, ok := cfg.userFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	cursorCreatedAt, cursorID := sql.NullTime{}, uuid.NullUUID{}
+	if page.Cursor != nil {
+		cursorCreatedAt = sql.NullTime{Time: page.Cursor.CreatedAt, Valid: true}
+		cursorID = uuid.NullUUID{UUID: page.Cursor.ID, Valid: true}
+	}
+	follows, err := list(user, page, cursorCreatedAt, cursorID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving follows", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(follows) > page.Limit {
+		follows = follows[:page.Limit]
+		last := follows[len(follows)-1]
+		nextCursor = pagination.Cursor{CreatedAt: last.FollowedAt, ID: last.UserID}.Encode()
+	}
+	pagination.SetLinkHeader(w, r, nextCursor, "")
+	respondWithJSON(w, http.StatusOK, pagination.Page[Follow]{
+		Items:      follows,
+		NextCursor: nextCursor,
+	})
+}
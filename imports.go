@@ -0,0 +1,252 @@
+package main
+
+import (
+	"GoServer/internal/archive"
+	"GoServer/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const maxImportBytes = 256 << 20
+
+// Why an archived chirp was not re-created.
+const (
+	skipExists   = "already_imported"
+	skipRechirp  = "rechirp"
+	skipTooLong  = "too_long"
+	skipRejected = "rejected_by_moderation"
+	skipInvalid  = "invalid"
+	skipMedia    = "unsupported_media"
+)
+
+// importSkipError marks an archived chirp that cannot be re-created, either
+// because it was imported before or because it no longer passes validation.
+type importSkipError struct {
+	Reason string
+}
+
+func (e *importSkipError) Error() string { return "chirp skipped: " + e.Reason }
+
+func skipImport(reason string) error {
+	return &importSkipError{Reason: reason}
+}
+
+// mediaReader is an uploaded archive that chirps being imported take their
+// media from.
+type mediaReader interface {
+	ReadMedia(file string, limit int64) ([]byte, error)
+}
+
+type ImportResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+// importArchive re-creates the chirps of an archive made by createExport,
+// keeping their timestamps. Chirps get new IDs; those already imported by
+// the caller are skipped, so importing the same archive twice is harmless.
+func (cfg *apiConfig) importArchive(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Archive is too large", nil)
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Missing file", err)
+		return
+	}
+	defer file.Close()
+
+	a, err := archive.Open(file, header.Size)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	limit, err := cfg.chirpLengthLimit(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving user", err)
+		return
+	}
+
+	// parents have to exist before their replies can point at them
+	sort.SliceStable(a.Chirps, func(i, j int) bool {
+		return a.Chirps[i].CreatedAt.Before(a.Chirps[j].CreatedAt)
+	})
+
+	var result ImportResult
+	ids := map[uuid.UUID]uuid.UUID{}
+	for _, record := range a.Chirps {
+		_, err := cfg.importChirp(r.Context(), userID, limit, a, record, ids, true)
+		var skip *importSkipError
+		if errors.As(err, &skip) {
+			result.Skipped++
+			continue
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error importing chirps", err)
+			return
+		}
+		result.Imported++
+	}
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// importChirp re-creates a single archived chirp with its media, under a
+// new ID. ids maps the archive IDs of the chirps imported so far to their
+// new IDs: replies and quotes of them are pointed at the new chirps, and
+// record is added once imported. Other parents and quoted chirps are looked
+// up by their archive ID. Rechirps are skipped, as they carry nothing of the
+// user's own. Mentions are only indexed when the handles in the body are
+// Chirpy handles.
+func (cfg *apiConfig) importChirp(ctx context.Context, userID uuid.UUID, limit int, media mediaReader, record archive.Chirp, ids map[uuid.UUID]uuid.UUID, mentions bool) (database.Chirp, error) {
+	if record.RechirpOfID != nil {
+		return database.Chirp{}, skipImport(skipRechirp)
+	}
+	imported, err := cfg.DB.GetImportedChirpID(ctx, database.GetImportedChirpIDParams{UserID: userID, SourceID: record.ID})
+	if err == nil {
+		ids[record.ID] = imported
+		return database.Chirp{}, skipImport(skipExists)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.Chirp{}, err
+	}
+	if len(record.Media) > maxChirpMedia {
+		return database.Chirp{}, skipImport(skipMedia)
+	}
+	visibility, err := parseVisibility(record.Visibility)
+	if err != nil {
+		return database.Chirp{}, skipImport(skipInvalid)
+	}
+	warning, err := cfg.parseContentWarning(record.ContentWarning, record.Sensitive)
+	if errors.Is(err, errRejectedByModeration) {
+		return database.Chirp{}, skipImport(skipRejected)
+	}
+	if err != nil {
+		return database.Chirp{}, skipImport(skipInvalid)
+	}
+	outcome, err := cfg.checkChirpBody(record.Body, limit)
+	if errors.Is(err, errRejectedByModeration) {
+		return database.Chirp{}, skipImport(skipRejected)
+	}
+	if err != nil {
+		return database.Chirp{}, skipImport(skipTooLong)
+	}
+	outcome.Decisions = append(outcome.Decisions, warning.Decisions...)
+	now := time.Now()
+	if record.CreatedAt.IsZero() || record.CreatedAt.After(now) {
+		return database.Chirp{}, skipImport(skipInvalid)
+	}
+
+	params := database.ImportChirpParams{
+		CreatedAt:      record.CreatedAt.UTC(),
+		UpdatedAt:      record.UpdatedAt.UTC(),
+		Body:           outcome.Body,
+		UserID:         userID,
+		Visibility:     visibility,
+		ContentWarning: warning.Text,
+		Sensitive:      warning.Sensitive,
+	}
+	if record.PublishAt != nil && record.PublishAt.After(now) {
+		// scheduled chirps carry their publish time as created_at
+		params.PublishAt = sql.NullTime{Time: record.PublishAt.UTC(), Valid: true}
+		params.CreatedAt = params.PublishAt.Time
+	}
+	if params.UpdatedAt.Before(params.CreatedAt) {
+		params.UpdatedAt = params.CreatedAt
+	}
+	if record.ParentID != nil {
+		params.ParentID = importedID(ids, *record.ParentID)
+	}
+	if record.QuoteOfID != nil {
+		params.QuoteOfID = importedID(ids, *record.QuoteOfID)
+	}
+
+	var chirp database.Chirp
+	var blobs []string
+	err = cfg.withTx(ctx, func(q *database.Queries) error {
+		chirp, err = q.ImportChirp(ctx, params)
+		if err != nil {
+			return err
+		}
+		recorded, err := q.RecordChirpImport(ctx, database.RecordChirpImportParams{
+			UserID:   userID,
+			SourceID: record.ID,
+			ChirpID:  chirp.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if recorded == 0 {
+			// a concurrent import of the same archive got there first
+			return skipImport(skipExists)
+		}
+		if mentions {
+			err = cfg.indexChirpEntities(ctx, q, chirp)
+		} else {
+			err = indexChirpTags(ctx, q, chirp)
+		}
+		if err != nil {
+			return err
+		}
+		if err := recordModerationFlags(ctx, q, chirp.ID, outcome.Decisions); err != nil {
+			return err
+		}
+		if !chirp.PublishAt.Valid {
+			// backdated chirps land in timelines at their original time
+			if err := q.EnqueueFanout(ctx, chirp.ID); err != nil {
+				return err
+			}
+		}
+
+		mediaIDs := make([]uuid.UUID, 0, len(record.Media))
+		for _, m := range record.Media {
+			if validateAltText(m.AltText) != nil {
+				return skipImport(skipInvalid)
+			}
+			data, err := media.ReadMedia(m.File, cfg.MediaMaxBytes)
+			if err != nil {
+				return skipImport(skipMedia)
+			}
+			mediaFile, err := cfg.storeMedia(ctx, q, userID, data, m.AltText)
+			var invalid *invalidMediaError
+			if errors.As(err, &invalid) {
+				return skipImport(skipMedia)
+			}
+			if err != nil {
+				return err
+			}
+			blobs = append(blobs, mediaFile.StorageKey, mediaFile.ThumbnailKey)
+			mediaIDs = append(mediaIDs, mediaFile.ID)
+		}
+		return attachMedia(ctx, q, userID, chirp.ID, mediaIDs)
+	})
+	if err != nil {
+		cfg.deleteBlobs(blobs...)
+		return database.Chirp{}, err
+	}
+	ids[record.ID] = chirp.ID
+	return chirp, nil
+}
+
+// importedID is the chirp an archive ID refers to: the chirp it was
+// imported as when it is in ids, or the chirp with that ID otherwise.
+func importedID(ids map[uuid.UUID]uuid.UUID, archiveID uuid.UUID) uuid.NullUUID {
+	if id, ok := ids[archiveID]; ok {
+		return uuid.NullUUID{UUID: id, Valid: true}
+	}
+	return uuid.NullUUID{UUID: archiveID, Valid: true}
+}
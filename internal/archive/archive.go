@@ -0,0 +1,271 @@
+// Package archive reads and writes the personal data archives users export
+// from and import into Chirpy.
+//
+// An archive is a ZIP file holding:
+//
+//	manifest.json   format version and export time
+//	profile.json    the account
+//	chirps.json     every chirp, with its media
+//	chirps.csv      the same chirps as a spreadsheet
+//	likes.json      the chirps the user liked
+//	followers.json  the accounts following the user
+//	media/          the images attached to chirps
+package archive
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Version is the archive format written by Writer.
+const Version = 1
+
+const (
+	manifestFile  = "manifest.json"
+	profileFile   = "profile.json"
+	chirpsFile    = "chirps.json"
+	chirpsCSVFile = "chirps.csv"
+	likesFile     = "likes.json"
+	followersFile = "followers.json"
+	mediaDir      = "media/"
+
+	// maxChirpsSize bounds how much of chirps.json Open will read.
+	maxChirpsSize = 64 << 20
+)
+
+// MaxChirps is the most chirps an archive may hold. Archives are imported
+// within one request.
+const MaxChirps = 10000
+
+var (
+	ErrInvalid  = errors.New("not a Chirpy archive")
+	ErrTooLarge = errors.New("archive file is too large")
+	ErrTooMany  = fmt.Errorf("archive has more than %d chirps", MaxChirps)
+)
+
+type Manifest struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+type Profile struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Email       string    `json:"email"`
+	Handle      string    `json:"handle,omitempty"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+}
+
+type Chirp struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Body        string     `json:"body"`
+	Visibility  string     `json:"visibility"`
+	ParentID    *uuid.UUID `json:"parent_id,omitempty"`
+	QuoteOfID   *uuid.UUID `json:"quote_of_id,omitempty"`
+	RechirpOfID *uuid.UUID `json:"rechirp_of_id,omitempty"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	Media       []Media    `json:"media,omitempty"`
+	// ContentWarning and Sensitive are missing from archives exported
+	// before chirps had content warnings.
+	ContentWarning string `json:"content_warning,omitempty"`
+	Sensitive      bool   `json:"sensitive,omitempty"`
+}
+
+// Media is an image attached to a chirp. File is its path inside the
+// archive.
+type Media struct {
+	File        string `json:"file"`
+	ContentType string `json:"content_type"`
+	AltText     string `json:"alt_text,omitempty"`
+}
+
+type Like struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Follower struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Handle    string    `json:"handle,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MediaFile is the path inside the archive of the image stored under name.
+func MediaFile(name string) string {
+	return mediaDir + name
+}
+
+// Writer writes an archive. The caller writes each part once and then
+// closes the Writer.
+type Writer struct {
+	zw *zip.Writer
+}
+
+func NewWriter(w io.Writer, exportedAt time.Time) (*Writer, error) {
+	aw := &Writer{zw: zip.NewWriter(w)}
+	if err := aw.writeJSON(manifestFile, Manifest{Version: Version, ExportedAt: exportedAt.UTC()}); err != nil {
+		return nil, err
+	}
+	return aw, nil
+}
+
+func (w *Writer) WriteProfile(profile Profile) error {
+	return w.writeJSON(profileFile, profile)
+}
+
+func (w *Writer) WriteLikes(likes []Like) error {
+	return w.writeJSON(likesFile, likes)
+}
+
+func (w *Writer) WriteFollowers(followers []Follower) error {
+	return w.writeJSON(followersFile, followers)
+}
+
+// WriteChirps writes chirps both as JSON and as CSV.
+func (w *Writer) WriteChirps(chirps []Chirp) error {
+	if err := w.writeJSON(chirpsFile, chirps); err != nil {
+		return err
+	}
+	f, err := w.zw.Create(chirpsCSVFile)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(f)
+	cw.Write([]string{"id", "created_at", "updated_at", "body", "visibility", "parent_id", "quote_of_id", "rechirp_of_id", "media"})
+	for _, chirp := range chirps {
+		media := make([]string, len(chirp.Media))
+		for i, m := range chirp.Media {
+			media[i] = m.File
+		}
+		cw.Write([]string{
+			chirp.ID.String(),
+			chirp.CreatedAt.UTC().Format(time.RFC3339),
+			chirp.UpdatedAt.UTC().Format(time.RFC3339),
+			chirp.Body,
+			chirp.Visibility,
+			optionalID(chirp.ParentID),
+			optionalID(chirp.QuoteOfID),
+			optionalID(chirp.RechirpOfID),
+			strings.Join(media, " "),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMedia copies an image into the archive under MediaFile(name).
+func (w *Writer) WriteMedia(name string, r io.Reader) error {
+	f, err := w.zw.CreateHeader(&zip.FileHeader{Name: MediaFile(name), Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	return err
+}
+
+func (w *Writer) Close() error {
+	return w.zw.Close()
+}
+
+func (w *Writer) writeJSON(name string, v any) error {
+	f, err := w.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func optionalID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+// Archive is an opened archive. Only the chirps are read up front; media is
+// read on demand.
+type Archive struct {
+	Manifest Manifest
+	Chirps   []Chirp
+	files    map[string]*zip.File
+}
+
+func Open(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	a := &Archive{files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		a.files[f.Name] = f
+	}
+
+	if err := a.readJSON(manifestFile, 1<<10, &a.Manifest); err != nil {
+		return nil, err
+	}
+	if a.Manifest.Version != Version {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalid, a.Manifest.Version)
+	}
+	if err := a.readJSON(chirpsFile, maxChirpsSize, &a.Chirps); err != nil {
+		return nil, err
+	}
+	if len(a.Chirps) > MaxChirps {
+		return nil, ErrTooMany
+	}
+	return a, nil
+}
+
+// ReadMedia returns the contents of an image referenced by a chirp, reading
+// at most limit bytes.
+func (a *Archive) ReadMedia(file string, limit int64) ([]byte, error) {
+	if !strings.HasPrefix(file, mediaDir) || path.Clean(file) != file {
+		return nil, ErrInvalid
+	}
+	return a.read(file, limit)
+}
+
+func (a *Archive) readJSON(name string, limit int64, v any) error {
+	data, err := a.read(name, limit)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: malformed %s", ErrInvalid, name)
+	}
+	return nil
+}
+
+// read never trusts the sizes recorded in the ZIP, so a compressed entry
+// cannot expand past limit.
+func (a *Archive) read(name string, limit int64) ([]byte, error) {
+	f, ok := a.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalid, name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: %s", ErrTooLarge, name)
+	}
+	return data, nil
+}
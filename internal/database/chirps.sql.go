@@ -7,29 +7,108 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const cancelScheduledChirp = `-- name: CancelScheduledChirp :execrows
+DELETE FROM chirps
+WHERE id = $1
+  AND publish_at IS NOT NULL
+`
+
+func (q *Queries) CancelScheduledChirp(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, cancelScheduledChirp, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, quote_of_id, publish_at, visibility, content_warning, sensitive)
 VALUES (
     gen_random_uuid(),
-    NOW(),
+    COALESCE($6::timestamp, NOW()),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6::timestamp,
+    $7,
+    $8,
+    $9
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body           string
+	UserID         uuid.UUID
+	ParentID       uuid.NullUUID
+	RootID         uuid.NullUUID
+	QuoteOfID      uuid.NullUUID
+	PublishAt      sql.NullTime
+	Visibility     string
+	ContentWarning sql.NullString
+	Sensitive      bool
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID, arg.ParentID, arg.RootID, arg.QuoteOfID, arg.PublishAt, arg.Visibility, arg.ContentWarning, arg.Sensitive)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.EditCount,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.PullOnRead,
+		&i.ModeratorWarning,
+		&i.DeletionKind,
+	)
+	return i, err
+}
+
+const createRechirp = `-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, rechirp_of_id)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    '',
+    $1,
+    $2
+)
+ON CONFLICT (user_id, rechirp_of_id) WHERE rechirp_of_id IS NOT NULL AND deleted_at IS NULL DO NOTHING
+RETURNING id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind
+`
+
+type CreateRechirpParams struct {
+	UserID      uuid.UUID
+	RechirpOfID uuid.NullUUID
+}
+
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRechirp, arg.UserID, arg.RechirpOfID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +116,22 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.EditCount,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.PullOnRead,
+		&i.ModeratorWarning,
+		&i.DeletionKind,
 	)
 	return i, err
 }
@@ -51,8 +146,47 @@ func (q *Queries) DeleteChirpByID(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const getChirpAncestorIDs = `-- name: GetChirpAncestorIDs :many
+WITH RECURSIVE ancestors AS (
+    SELECT parent_id, 1 AS depth
+    FROM chirps
+    WHERE id = $1::uuid
+    UNION ALL
+    SELECT c.parent_id, a.depth + 1
+    FROM chirps c
+    JOIN ancestors a ON c.id = a.parent_id
+)
+SELECT parent_id::uuid AS id
+FROM ancestors
+WHERE parent_id IS NOT NULL
+ORDER BY depth DESC
+`
+
+func (q *Queries) GetChirpAncestorIDs(ctx context.Context, chirpID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestorIDs, chirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirpByID = `-- name: GetChirpByID :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind FROM chirps
 WHERE id = $1
 `
 
@@ -65,17 +199,543 @@ func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.EditCount,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.PullOnRead,
+		&i.ModeratorWarning,
+		&i.DeletionKind,
+	)
+	return i, err
+}
+
+const getChirpByIDForUpdate = `-- name: GetChirpByIDForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind FROM chirps
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetChirpByIDForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpByIDForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.EditCount,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.PullOnRead,
+		&i.ModeratorWarning,
+		&i.DeletionKind,
+	)
+	return i, err
+}
+
+const getChirpDescendants = `-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT id, 1 AS depth, to_char(created_at, 'YYYYMMDDHH24MISSUS') || id::text AS path
+    FROM chirps
+    WHERE parent_id = $1::uuid
+    UNION ALL
+    SELECT c.id, d.depth + 1, d.path || '/' || to_char(c.created_at, 'YYYYMMDDHH24MISSUS') || c.id::text
+    FROM chirps c
+    JOIN descendants d ON c.parent_id = d.id
+    WHERE d.depth < $2::int
+)
+SELECT id, depth, path
+FROM descendants
+WHERE $3::text IS NULL OR path > $3::text
+ORDER BY path
+LIMIT $4
+`
+
+type GetChirpDescendantsParams struct {
+	ChirpID    uuid.UUID
+	MaxDepth   int32
+	CursorPath sql.NullString
+	RowLimit   int32
+}
+
+type GetChirpDescendantsRow struct {
+	ID    uuid.UUID
+	Depth int32
+	Path  string
+}
+
+func (q *Queries) GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]GetChirpDescendantsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendants, arg.ChirpID, arg.MaxDepth, arg.CursorPath, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpDescendantsRow
+	for rows.Next() {
+		var i GetChirpDescendantsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Depth,
+			&i.Path,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind FROM chirps
+WHERE id = ANY($1::uuid[])
+  AND chirp_visible(id, user_id, visibility, publish_at, $2::uuid, false)
+`
+
+type GetChirpsByIDsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, arg GetChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(arg.Ids), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.EditCount,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.PublishAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.PullOnRead,
+			&i.ModeratorWarning,
+			&i.DeletionKind,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRechirpStats = `-- name: GetRechirpStats :many
+SELECT
+    original.id AS chirp_id,
+    COUNT(*) FILTER (WHERE chirps.rechirp_of_id = original.id) AS rechirp_count,
+    COUNT(*) FILTER (WHERE chirps.quote_of_id = original.id) AS quote_count
+FROM unnest($1::uuid[]) AS original(id)
+JOIN chirps ON chirps.rechirp_of_id = original.id OR chirps.quote_of_id = original.id
+WHERE chirps.deleted_at IS NULL
+GROUP BY original.id
+`
+
+type GetRechirpStatsRow struct {
+	ChirpID      uuid.UUID
+	RechirpCount int64
+	QuoteCount   int64
+}
+
+func (q *Queries) GetRechirpStats(ctx context.Context, chirpIds []uuid.UUID) ([]GetRechirpStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRechirpStats, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRechirpStatsRow
+	for rows.Next() {
+		var i GetRechirpStatsRow
+		if err := rows.Scan(
+			&i.ChirpID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReplyCounts = `-- name: GetReplyCounts :many
+SELECT parent_id::uuid AS chirp_id, COUNT(*) AS reply_count
+FROM chirps
+WHERE parent_id = ANY($1::uuid[])
+  AND deleted_at IS NULL
+  AND publish_at IS NULL
+  AND chirp_visible(id, user_id, visibility, publish_at, $2::uuid, false)
+GROUP BY parent_id
+`
+
+type GetReplyCountsParams struct {
+	ChirpIds []uuid.UUID
+	ViewerID uuid.UUID
+}
+
+type GetReplyCountsRow struct {
+	ChirpID    uuid.UUID
+	ReplyCount int64
+}
+
+func (q *Queries) GetReplyCounts(ctx context.Context, arg GetReplyCountsParams) ([]GetReplyCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getReplyCounts, pq.Array(arg.ChirpIds), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReplyCountsRow
+	for rows.Next() {
+		var i GetReplyCountsRow
+		if err := rows.Scan(
+			&i.ChirpID,
+			&i.ReplyCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserRechirp = `-- name: GetUserRechirp :one
+SELECT id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind FROM chirps
+WHERE user_id = $1
+  AND rechirp_of_id = $2
+  AND deleted_at IS NULL
+`
+
+type GetUserRechirpParams struct {
+	UserID      uuid.UUID
+	RechirpOfID uuid.NullUUID
+}
+
+func (q *Queries) GetUserRechirp(ctx context.Context, arg GetUserRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getUserRechirp, arg.UserID, arg.RechirpOfID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.EditCount,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.PullOnRead,
+		&i.ModeratorWarning,
+		&i.DeletionKind,
+	)
+	return i, err
+}
+
+const getVisibleChirpByID = `-- name: GetVisibleChirpByID :one
+SELECT id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind FROM chirps
+WHERE id = $1::uuid
+  AND chirp_visible(id, user_id, visibility, publish_at, $2::uuid, false)
+`
+
+type GetVisibleChirpByIDParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) GetVisibleChirpByID(ctx context.Context, arg GetVisibleChirpByIDParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getVisibleChirpByID, arg.ID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.EditCount,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.PullOnRead,
+		&i.ModeratorWarning,
+		&i.DeletionKind,
+	)
+	return i, err
+}
+
+const hasReplies = `-- name: HasReplies :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps
+    WHERE parent_id = $1
+)
+`
+
+func (q *Queries) HasReplies(ctx context.Context, parentID uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, hasReplies, parentID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const importChirp = `-- name: ImportChirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, quote_of_id, publish_at, visibility, content_warning, sensitive)
+SELECT
+    gen_random_uuid(),
+    $1::timestamp,
+    $2::timestamp,
+    $3::text,
+    $4::uuid,
+    parent.id,
+    COALESCE(parent.root_id, parent.id),
+    quoted.id,
+    $5::timestamp,
+    $6::text,
+    $7::text,
+    $8::boolean
+FROM (SELECT 1) AS one
+LEFT JOIN chirps AS parent
+    ON parent.id = $9::uuid AND parent.deleted_at IS NULL
+    AND parent.publish_at IS NULL
+    AND chirp_visible(parent.id, parent.user_id, parent.visibility, parent.publish_at, $4::uuid, false)
+LEFT JOIN chirps AS quoted
+    ON quoted.id = $10::uuid AND quoted.deleted_at IS NULL
+    AND quoted.visibility IN ('public', 'unlisted') AND quoted.publish_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind
+`
+
+type ImportChirpParams struct {
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Body           string
+	UserID         uuid.UUID
+	PublishAt      sql.NullTime
+	Visibility     string
+	ContentWarning sql.NullString
+	Sensitive      bool
+	ParentID       uuid.NullUUID
+	QuoteOfID      uuid.NullUUID
+}
+
+func (q *Queries) ImportChirp(ctx context.Context, arg ImportChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, importChirp, arg.CreatedAt, arg.UpdatedAt, arg.Body, arg.UserID, arg.PublishAt, arg.Visibility, arg.ContentWarning, arg.Sensitive, arg.ParentID, arg.QuoteOfID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.EditCount,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.PullOnRead,
+		&i.ModeratorWarning,
+		&i.DeletionKind,
 	)
 	return i, err
 }
 
-const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-ORDER BY created_at ASC
+const listChirpsAfter = `-- name: ListChirpsAfter :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind FROM chirps
+WHERE (deleted_at IS NULL OR deletion_kind = 'moderator')
+  AND chirp_visible(id, user_id, visibility, publish_at, $1::uuid, true)
+  AND ($2::uuid[] IS NULL OR user_id = ANY($2::uuid[]))
+  AND ($3::timestamp IS NULL OR created_at >= $3::timestamp)
+  AND ($4::timestamp IS NULL OR created_at < $4::timestamp)
+  AND ($5::boolean IS NULL
+       OR EXISTS (SELECT 1 FROM media_files WHERE media_files.chirp_id = chirps.id) = $5::boolean)
+  AND ($6::boolean IS NULL OR (parent_id IS NOT NULL) = $6::boolean)
+  AND ($7::text IS NULL OR EXISTS (
+       SELECT 1 FROM chirp_tags
+       JOIN tags ON tags.id = chirp_tags.tag_id
+       WHERE chirp_tags.chirp_id = chirps.id AND tags.name = $7::text
+  ))
+  AND ($8::timestamp IS NULL
+       OR (created_at, id) > ($8::timestamp, $9::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $10
+`
+
+type ListChirpsAfterParams struct {
+	ViewerID        uuid.UUID
+	AuthorIds       []uuid.UUID
+	Since           sql.NullTime
+	Until           sql.NullTime
+	HasMedia        sql.NullBool
+	IsReply         sql.NullBool
+	Tag             sql.NullString
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	RowLimit        int32
+}
+
+func (q *Queries) ListChirpsAfter(ctx context.Context, arg ListChirpsAfterParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAfter, arg.ViewerID, pq.Array(arg.AuthorIds), arg.Since, arg.Until, arg.HasMedia, arg.IsReply, arg.Tag, arg.CursorCreatedAt, arg.CursorID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.EditCount,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.PublishAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.PullOnRead,
+			&i.ModeratorWarning,
+			&i.DeletionKind,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsBefore = `-- name: ListChirpsBefore :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind FROM chirps
+WHERE (deleted_at IS NULL OR deletion_kind = 'moderator')
+  AND chirp_visible(id, user_id, visibility, publish_at, $1::uuid, true)
+  AND ($2::uuid[] IS NULL OR user_id = ANY($2::uuid[]))
+  AND ($3::timestamp IS NULL OR created_at >= $3::timestamp)
+  AND ($4::timestamp IS NULL OR created_at < $4::timestamp)
+  AND ($5::boolean IS NULL
+       OR EXISTS (SELECT 1 FROM media_files WHERE media_files.chirp_id = chirps.id) = $5::boolean)
+  AND ($6::boolean IS NULL OR (parent_id IS NOT NULL) = $6::boolean)
+  AND ($7::text IS NULL OR EXISTS (
+       SELECT 1 FROM chirp_tags
+       JOIN tags ON tags.id = chirp_tags.tag_id
+       WHERE chirp_tags.chirp_id = chirps.id AND tags.name = $7::text
+  ))
+  AND ($8::timestamp IS NULL
+       OR (created_at, id) < ($8::timestamp, $9::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $10
 `
 
-func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps)
+type ListChirpsBeforeParams struct {
+	ViewerID        uuid.UUID
+	AuthorIds       []uuid.UUID
+	Since           sql.NullTime
+	Until           sql.NullTime
+	HasMedia        sql.NullBool
+	IsReply         sql.NullBool
+	Tag             sql.NullString
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	RowLimit        int32
+}
+
+func (q *Queries) ListChirpsBefore(ctx context.Context, arg ListChirpsBeforeParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsBefore, arg.ViewerID, pq.Array(arg.AuthorIds), arg.Since, arg.Until, arg.HasMedia, arg.IsReply, arg.Tag, arg.CursorCreatedAt, arg.CursorID, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -89,6 +749,95 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.EditCount,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.PublishAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.PullOnRead,
+			&i.ModeratorWarning,
+			&i.DeletionKind,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsByEngagement = `-- name: ListChirpsByEngagement :many
+SELECT chirps.id, engagement.score
+FROM chirps
+CROSS JOIN LATERAL (
+    SELECT (SELECT COUNT(*) FROM likes WHERE likes.chirp_id = chirps.id)
+         + (SELECT COUNT(*) FROM chirps AS other
+            WHERE other.deleted_at IS NULL
+              AND (other.parent_id = chirps.id OR other.rechirp_of_id = chirps.id OR other.quote_of_id = chirps.id)) AS score
+) AS engagement
+WHERE chirps.deleted_at IS NULL
+  AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, chirps.publish_at, $1::uuid, true)
+  AND ($2::uuid[] IS NULL OR chirps.user_id = ANY($2::uuid[]))
+  AND chirps.created_at >= $3::timestamp
+  AND ($4::timestamp IS NULL OR chirps.created_at < $4::timestamp)
+  AND ($5::boolean IS NULL
+       OR EXISTS (SELECT 1 FROM media_files WHERE media_files.chirp_id = chirps.id) = $5::boolean)
+  AND ($6::boolean IS NULL OR (chirps.parent_id IS NOT NULL) = $6::boolean)
+  AND ($7::text IS NULL OR EXISTS (
+       SELECT 1 FROM chirp_tags
+       JOIN tags ON tags.id = chirp_tags.tag_id
+       WHERE chirp_tags.chirp_id = chirps.id AND tags.name = $7::text
+  ))
+  AND ($8::bigint IS NULL
+       OR engagement.score < $8::bigint
+       OR (engagement.score = $8::bigint AND chirps.id > $9::uuid))
+ORDER BY engagement.score DESC, chirps.id ASC
+LIMIT $10
+`
+
+type ListChirpsByEngagementParams struct {
+	ViewerID    uuid.UUID
+	AuthorIds   []uuid.UUID
+	Since       time.Time
+	Until       sql.NullTime
+	HasMedia    sql.NullBool
+	IsReply     sql.NullBool
+	Tag         sql.NullString
+	CursorScore sql.NullInt64
+	CursorID    uuid.NullUUID
+	RowLimit    int32
+}
+
+type ListChirpsByEngagementRow struct {
+	ID    uuid.UUID
+	Score int64
+}
+
+func (q *Queries) ListChirpsByEngagement(ctx context.Context, arg ListChirpsByEngagementParams) ([]ListChirpsByEngagementRow, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsByEngagement, arg.ViewerID, pq.Array(arg.AuthorIds), arg.Since, arg.Until, arg.HasMedia, arg.IsReply, arg.Tag, arg.CursorScore, arg.CursorID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListChirpsByEngagementRow
+	for rows.Next() {
+		var i ListChirpsByEngagementRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Score,
 		); err != nil {
 			return nil, err
 		}
@@ -103,14 +852,80 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 	return items, nil
 }
 
-const getChirpsByUserID = `-- name: GetChirpsByUserID :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+const listScheduledChirps = `-- name: ListScheduledChirps :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind FROM chirps
 WHERE user_id = $1
-ORDER BY created_at ASC
+  AND publish_at IS NOT NULL
+  AND deleted_at IS NULL
+ORDER BY publish_at ASC, id ASC
+`
+
+func (q *Queries) ListScheduledChirps(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduledChirps, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.EditCount,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.PublishAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.PullOnRead,
+			&i.ModeratorWarning,
+			&i.DeletionKind,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const publishDueChirps = `-- name: PublishDueChirps :many
+UPDATE chirps
+SET publish_at = NULL
+WHERE id IN (
+    SELECT id FROM chirps
+    WHERE publish_at <= $1
+      AND deleted_at IS NULL
+    ORDER BY publish_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind
 `
 
-func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByUserID, userID)
+type PublishDueChirpsParams struct {
+	Now   time.Time
+	Limit int32
+}
+
+func (q *Queries) PublishDueChirps(ctx context.Context, arg PublishDueChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueChirps, arg.Now, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -124,6 +939,22 @@ func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Ch
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.EditCount,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.PublishAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.PullOnRead,
+			&i.ModeratorWarning,
+			&i.DeletionKind,
 		); err != nil {
 			return nil, err
 		}
@@ -137,3 +968,320 @@ func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Ch
 	}
 	return items, nil
 }
+
+const purgeDeletedChirps = `-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE id IN (
+    SELECT c.id FROM chirps c
+    WHERE c.deleted_at <= NOW() - make_interval(secs => $1::float8)
+      AND NOT EXISTS (SELECT 1 FROM chirps r WHERE r.parent_id = c.id)
+      AND NOT EXISTS (SELECT 1 FROM report_cases rc WHERE rc.target_chirp_id = c.id)
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+`
+
+type PurgeDeletedChirpsParams struct {
+	RetentionSeconds float64
+	RowLimit         int32
+}
+
+func (q *Queries) PurgeDeletedChirps(ctx context.Context, arg PurgeDeletedChirpsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedChirps, arg.RetentionSeconds, arg.RowLimit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const rescheduleChirp = `-- name: RescheduleChirp :one
+UPDATE chirps
+SET publish_at = $1, created_at = $1, updated_at = NOW()
+WHERE id = $2
+  AND publish_at IS NOT NULL
+RETURNING id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind
+`
+
+type RescheduleChirpParams struct {
+	PublishAt sql.NullTime
+	ID        uuid.UUID
+}
+
+func (q *Queries) RescheduleChirp(ctx context.Context, arg RescheduleChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, rescheduleChirp, arg.PublishAt, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.EditCount,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.PullOnRead,
+		&i.ModeratorWarning,
+		&i.DeletionKind,
+	)
+	return i, err
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps
+SET
+  deleted_at = NULL,
+  updated_at = NOW(),
+  deleted_by = NULL,
+  deletion_kind = NULL,
+  deletion_reason = NULL
+WHERE id = $1::uuid
+  AND deleted_at > NOW() - make_interval(secs => $2::float8)
+RETURNING id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind
+`
+
+type RestoreChirpParams struct {
+	ID               uuid.UUID
+	RetentionSeconds float64
+}
+
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, arg.ID, arg.RetentionSeconds)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.EditCount,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.PullOnRead,
+		&i.ModeratorWarning,
+		&i.DeletionKind,
+	)
+	return i, err
+}
+
+const scrubDeletedChirps = `-- name: ScrubDeletedChirps :execrows
+UPDATE chirps
+SET body = ''
+WHERE deleted_at <= NOW() - make_interval(secs => $1::float8)
+  AND body <> ''
+  AND NOT EXISTS (SELECT 1 FROM report_cases WHERE report_cases.target_chirp_id = chirps.id)
+`
+
+func (q *Queries) ScrubDeletedChirps(ctx context.Context, retentionSeconds float64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, scrubDeletedChirps, retentionSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT id,
+    ts_rank(search_vector, to_tsquery('simple', $1::text))::float8 AS rank,
+    ts_headline(
+        'simple',
+        replace(replace(replace(body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+        to_tsquery('simple', $1::text),
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2'
+    ) AS snippet
+FROM chirps
+WHERE search_vector @@ to_tsquery('simple', $1::text)
+  AND deleted_at IS NULL
+  AND publish_at IS NULL
+  AND chirp_visible(id, user_id, visibility, publish_at, $2::uuid, true)
+  AND ($3::uuid IS NULL OR user_id = $3::uuid)
+  AND ($4::float8 IS NULL
+       OR ts_rank(search_vector, to_tsquery('simple', $1::text))::float8 < $4::float8
+       OR (ts_rank(search_vector, to_tsquery('simple', $1::text))::float8 = $4::float8
+           AND id > $5::uuid))
+ORDER BY rank DESC, id ASC
+LIMIT $6
+`
+
+type SearchChirpsParams struct {
+	Query      string
+	ViewerID   uuid.UUID
+	AuthorID   uuid.NullUUID
+	CursorRank sql.NullFloat64
+	CursorID   uuid.NullUUID
+	RowLimit   int32
+}
+
+type SearchChirpsRow struct {
+	ID      uuid.UUID
+	Rank    float64
+	Snippet string
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps, arg.Query, arg.ViewerID, arg.AuthorID, arg.CursorRank, arg.CursorID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setChirpContentWarning = `-- name: SetChirpContentWarning :one
+UPDATE chirps
+SET
+  content_warning = $1,
+  sensitive = $2,
+  moderator_warning = $1 IS NOT NULL OR $2
+WHERE id = $3
+  AND deleted_at IS NULL
+  AND rechirp_of_id IS NULL
+RETURNING id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind
+`
+
+type SetChirpContentWarningParams struct {
+	ContentWarning sql.NullString
+	Sensitive      bool
+	ID             uuid.UUID
+}
+
+func (q *Queries) SetChirpContentWarning(ctx context.Context, arg SetChirpContentWarningParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, setChirpContentWarning, arg.ContentWarning, arg.Sensitive, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.EditCount,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.PullOnRead,
+		&i.ModeratorWarning,
+		&i.DeletionKind,
+	)
+	return i, err
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :execrows
+UPDATE chirps
+SET
+  deleted_at = NOW(),
+  updated_at = NOW(),
+  deleted_by = $1,
+  deletion_kind = $2,
+  deletion_reason = $3
+WHERE id = $4
+  AND deleted_at IS NULL
+`
+
+type SoftDeleteChirpParams struct {
+	DeletedBy      uuid.NullUUID
+	DeletionKind   sql.NullString
+	DeletionReason sql.NullString
+	ID             uuid.UUID
+}
+
+func (q *Queries) SoftDeleteChirp(ctx context.Context, arg SoftDeleteChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, softDeleteChirp, arg.DeletedBy, arg.DeletionKind, arg.DeletionReason, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateChirpContent = `-- name: UpdateChirpContent :one
+UPDATE chirps
+SET
+  body = $1,
+  content_warning = $2,
+  sensitive = $3,
+  edit_count = edit_count + 1,
+  updated_at = NOW()
+WHERE id = $4
+RETURNING id, created_at, updated_at, body, user_id, search_vector, edit_count, parent_id, root_id, deleted_at, rechirp_of_id, quote_of_id, publish_at, deleted_by, deletion_reason, visibility, content_warning, sensitive, pull_on_read, moderator_warning, deletion_kind
+`
+
+type UpdateChirpContentParams struct {
+	Body           string
+	ContentWarning sql.NullString
+	Sensitive      bool
+	ID             uuid.UUID
+}
+
+func (q *Queries) UpdateChirpContent(ctx context.Context, arg UpdateChirpContentParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpContent, arg.Body, arg.ContentWarning, arg.Sensitive, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.EditCount,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.PullOnRead,
+		&i.ModeratorWarning,
+		&i.DeletionKind,
+	)
+	return i, err
+}
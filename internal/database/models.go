@@ -11,12 +11,144 @@ import (
 	"github.com/google/uuid"
 )
 
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Body             string
+	UserID           uuid.UUID
+	SearchVector     interface{}
+	EditCount        int32
+	ParentID         uuid.NullUUID
+	RootID           uuid.NullUUID
+	DeletedAt        sql.NullTime
+	RechirpOfID      uuid.NullUUID
+	QuoteOfID        uuid.NullUUID
+	PublishAt        sql.NullTime
+	DeletedBy        uuid.NullUUID
+	DeletionReason   sql.NullString
+	Visibility       string
+	ContentWarning   sql.NullString
+	Sensitive        bool
+	PullOnRead       bool
+	ModeratorWarning bool
+	DeletionKind     sql.NullString
+}
+
+type ChirpImport struct {
+	UserID   uuid.UUID
+	SourceID uuid.UUID
+	ChirpID  uuid.UUID
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	Body       string
+	CreatedAt  time.Time
+	ReplacedAt time.Time
+}
+
+type ChirpTag struct {
+	ChirpID   uuid.UUID
+	TagID     uuid.UUID
+	CreatedAt time.Time
+}
+
+type Draft struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	UserID         uuid.UUID
+	Body           string
+	ContentWarning sql.NullString
+	Sensitive      bool
+}
+
+type ExportJob struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Status      string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CompletedAt sql.NullTime
+	StorageKey  sql.NullString
+	SizeBytes   sql.NullInt64
+	Error       sql.NullString
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Like struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type MediaFile struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UserID               uuid.UUID
+	ChirpID              uuid.NullUUID
+	ContentType          string
+	SizeBytes            int64
+	Width                int32
+	Height               int32
+	StorageKey           string
+	ThumbnailKey         string
+	ThumbnailContentType string
+	AltText              string
+}
+
+type Mention struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Handle    string
+	CreatedAt time.Time
+}
+
+type ModerationFlag struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
-	Body      string
+	ChirpID   uuid.UUID
+	Rule      string
+}
+
+type Poll struct {
+	ChirpID        uuid.UUID
+	CreatedAt      time.Time
+	ClosesAt       time.Time
+	MultipleChoice bool
+	HideResults    bool
+}
+
+type PollOption struct {
+	ID       uuid.UUID
+	ChirpID  uuid.UUID
+	Position int32
+	Text     string
+}
+
+type PollVote struct {
+	ChirpID  uuid.UUID
+	OptionID uuid.UUID
+	UserID   uuid.UUID
+}
+
+type PollVoter struct {
+	ChirpID   uuid.UUID
 	UserID    uuid.UUID
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,11 +160,66 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
-type User struct {
+type Report struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	CaseID     uuid.UUID
+	ReporterID uuid.UUID
+	Category   string
+	Details    string
+}
+
+type ReportAction struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+	CaseID         uuid.UUID
+	ModeratorID    uuid.NullUUID
+	Action         string
+	Note           string
+	SuspendedUntil sql.NullTime
+}
+
+type ReportCase struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	TargetUserID  uuid.UUID
+	TargetChirpID uuid.NullUUID
+	Status        string
+	AssigneeID    uuid.NullUUID
+	Notes         string
+	ResolvedAt    sql.NullTime
+}
+
+type Tag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Name      string
+}
+
+type TimelineEntry struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	AuthorID  uuid.UUID
+	CreatedAt time.Time
+}
+
+type TimelineFanoutJob struct {
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type User struct {
+	ID                    uuid.UUID
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	Email                 string
+	HashedPassword        string
+	IsChirpyRed           bool
+	Handle                sql.NullString
+	Role                  string
+	ExpandContentWarnings bool
+	SuspendedUntil        sql.NullTime
+	FollowerCount         int32
+	HasPulledChirps       bool
 }
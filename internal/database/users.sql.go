@@ -7,8 +7,10 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -20,7 +22,7 @@ VALUES (
     $1,
     $2
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, role, expand_content_warnings, suspended_until, follower_count, has_pulled_chirps
 `
 
 type CreateUserParams struct {
@@ -38,6 +40,12 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.Role,
+		&i.ExpandContentWarnings,
+		&i.SuspendedUntil,
+		&i.FollowerCount,
+		&i.HasPulledChirps,
 	)
 	return i, err
 }
@@ -52,7 +60,7 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, role, expand_content_warnings, suspended_until, follower_count, has_pulled_chirps FROM users
 WHERE email = $1
 `
 
@@ -66,12 +74,18 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.Role,
+		&i.ExpandContentWarnings,
+		&i.SuspendedUntil,
+		&i.FollowerCount,
+		&i.HasPulledChirps,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, role, expand_content_warnings, suspended_until, follower_count, has_pulled_chirps FROM users
 WHERE id = $1
 `
 
@@ -85,6 +99,189 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.Role,
+		&i.ExpandContentWarnings,
+		&i.SuspendedUntil,
+		&i.FollowerCount,
+		&i.HasPulledChirps,
+	)
+	return i, err
+}
+
+const getUsersByHandles = `-- name: GetUsersByHandles :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, role, expand_content_warnings, suspended_until, follower_count, has_pulled_chirps FROM users
+WHERE lower(handle) = ANY($1::text[])
+`
+
+func (q *Queries) GetUsersByHandles(ctx context.Context, handles []string) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByHandles, pq.Array(handles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.Handle,
+			&i.Role,
+			&i.ExpandContentWarnings,
+			&i.SuspendedUntil,
+			&i.FollowerCount,
+			&i.HasPulledChirps,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setUserExpandContentWarnings = `-- name: SetUserExpandContentWarnings :one
+UPDATE users
+SET
+  expand_content_warnings = $1,
+  updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, role, expand_content_warnings, suspended_until, follower_count, has_pulled_chirps
+`
+
+type SetUserExpandContentWarningsParams struct {
+	ExpandContentWarnings bool
+	ID                    uuid.UUID
+}
+
+func (q *Queries) SetUserExpandContentWarnings(ctx context.Context, arg SetUserExpandContentWarningsParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserExpandContentWarnings, arg.ExpandContentWarnings, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.Role,
+		&i.ExpandContentWarnings,
+		&i.SuspendedUntil,
+		&i.FollowerCount,
+		&i.HasPulledChirps,
+	)
+	return i, err
+}
+
+const setUserHandle = `-- name: SetUserHandle :one
+UPDATE users
+SET
+  handle = $1,
+  updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, role, expand_content_warnings, suspended_until, follower_count, has_pulled_chirps
+`
+
+type SetUserHandleParams struct {
+	Handle sql.NullString
+	ID     uuid.UUID
+}
+
+func (q *Queries) SetUserHandle(ctx context.Context, arg SetUserHandleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserHandle, arg.Handle, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.Role,
+		&i.ExpandContentWarnings,
+		&i.SuspendedUntil,
+		&i.FollowerCount,
+		&i.HasPulledChirps,
+	)
+	return i, err
+}
+
+const setUserRole = `-- name: SetUserRole :one
+UPDATE users
+SET
+  role = $1,
+  updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, role, expand_content_warnings, suspended_until, follower_count, has_pulled_chirps
+`
+
+type SetUserRoleParams struct {
+	Role string
+	ID   uuid.UUID
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRole, arg.Role, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.Role,
+		&i.ExpandContentWarnings,
+		&i.SuspendedUntil,
+		&i.FollowerCount,
+		&i.HasPulledChirps,
+	)
+	return i, err
+}
+
+const suspendUser = `-- name: SuspendUser :one
+UPDATE users
+SET
+  suspended_until = $1,
+  updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, role, expand_content_warnings, suspended_until, follower_count, has_pulled_chirps
+`
+
+type SuspendUserParams struct {
+	SuspendedUntil sql.NullTime
+	ID             uuid.UUID
+}
+
+func (q *Queries) SuspendUser(ctx context.Context, arg SuspendUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, suspendUser, arg.SuspendedUntil, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.Role,
+		&i.ExpandContentWarnings,
+		&i.SuspendedUntil,
+		&i.FollowerCount,
+		&i.HasPulledChirps,
 	)
 	return i, err
 }
@@ -96,7 +293,7 @@ SET
   hashed_password = $2,
   updated_at = NOW()
 WHERE id = $3
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, role, expand_content_warnings, suspended_until, follower_count, has_pulled_chirps
 `
 
 type UpdateUserParams struct {
@@ -115,6 +312,12 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.Role,
+		&i.ExpandContentWarnings,
+		&i.SuspendedUntil,
+		&i.FollowerCount,
+		&i.HasPulledChirps,
 	)
 	return i, err
 }
@@ -125,7 +328,7 @@ SET
   is_chirpy_red = true,
   updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, role, expand_content_warnings, suspended_until, follower_count, has_pulled_chirps
 `
 
 func (q *Queries) UpgradeUserToChirpyRed(ctx context.Context, id uuid.UUID) (User, error) {
@@ -138,6 +341,12 @@ func (q *Queries) UpgradeUserToChirpyRed(ctx context.Context, id uuid.UUID) (Use
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.Role,
+		&i.ExpandContentWarnings,
+		&i.SuspendedUntil,
+		&i.FollowerCount,
+		&i.HasPulledChirps,
 	)
 	return i, err
 }
@@ -0,0 +1,149 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TypeHashtag = "hashtag"
+	TypeMention = "mention"
+
+	// Formatting and links, found by the markup package.
+	TypeBold   = "bold"
+	TypeItalic = "italic"
+	TypeCode   = "code"
+	TypeLink   = "link"
+
+	maxTagLen    = 100
+	maxHandleLen = 30
+)
+
+// Entity is a span of a chirp body with a special meaning. Start and End are
+// offsets in characters (Unicode code points), End exclusive.
+type Entity struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Value string `json:"value"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	// UserID is set on mentions that resolved to a user.
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// NormalizeTag case-folds a tag so #Go, #GO and #go are the same tag.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+// Hashtags returns the #tags in text in order of appearance. A tag has to
+// start a word and contain at least one non-digit, so "#1" or "a#b" are not
+// tags.
+func Hashtags(text string) []Entity {
+	return scan(text, '#', TypeHashtag, func(word []rune) (string, bool) {
+		if len(word) > maxTagLen {
+			return "", false
+		}
+		for _, r := range word {
+			if !unicode.IsDigit(r) {
+				return NormalizeTag(string(word)), true
+			}
+		}
+		return "", false
+	})
+}
+
+// ValidHandle reports whether handle can be used as a user handle: 1 to 30
+// ASCII letters, digits or underscores.
+func ValidHandle(handle string) bool {
+	if handle == "" || len(handle) > maxHandleLen {
+		return false
+	}
+	for _, r := range handle {
+		if r > unicode.MaxASCII || !isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeHandle case-folds a handle, since handles are unique regardless
+// of case.
+func NormalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(handle, "@"))
+}
+
+// Mentions returns the @handles in text in order of appearance. Like tags,
+// a mention has to start a word, which keeps e-mail addresses out.
+func Mentions(text string) []Entity {
+	return scan(text, '@', TypeMention, func(word []rune) (string, bool) {
+		handle := string(word)
+		if !ValidHandle(handle) {
+			return "", false
+		}
+		return NormalizeHandle(handle), true
+	})
+}
+
+// OfType returns the entities of the given type, keeping their order.
+func OfType(entities []Entity, kind string) []Entity {
+	var found []Entity
+	for _, entity := range entities {
+		if entity.Type == kind {
+			found = append(found, entity)
+		}
+	}
+	return found
+}
+
+// UniqueValues returns the distinct values of entities, keeping their order.
+func UniqueValues(entities []Entity) []string {
+	seen := map[string]bool{}
+	var values []string
+	for _, entity := range entities {
+		if seen[entity.Value] {
+			continue
+		}
+		seen[entity.Value] = true
+		values = append(values, entity.Value)
+	}
+	return values
+}
+
+func scan(text string, sigil rune, kind string, accept func(word []rune) (string, bool)) []Entity {
+	runes := []rune(text)
+	var found []Entity
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != sigil {
+			continue
+		}
+		if i > 0 && (isWordRune(runes[i-1]) || runes[i-1] == '&' || runes[i-1] == sigil) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && isWordRune(runes[end]) {
+			end++
+		}
+		if end == i+1 {
+			continue
+		}
+		value, ok := accept(runes[i+1 : end])
+		if ok {
+			found = append(found, Entity{
+				Type:  kind,
+				Text:  string(runes[i:end]),
+				Value: value,
+				Start: i,
+				End:   end,
+			})
+		}
+		i = end - 1
+	}
+	return found
+}
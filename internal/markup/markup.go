@@ -0,0 +1,402 @@
+// Package markup parses the small set of inline formatting chirps support
+// and renders it as HTML:
+//
+//	**bold**, *italic* or _italic_, `code`, [text](https://link),
+//	bare https:// links, @mentions and #hashtags
+//
+// Formatting never nests inside itself and there are no block elements, so
+// every body has exactly one rendering. Anything that does not parse as
+// markup stays literal text.
+package markup
+
+import (
+	"GoServer/internal/entities"
+	"html"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+const kindText = "text"
+
+type node struct {
+	kind string
+	// start and end delimit the node in the source, markers included, in
+	// code points.
+	start, end int
+	// value is the text of text and code nodes, the inner source of bold and
+	// italic, the URL of links and the normalized tag or handle otherwise.
+	value    string
+	children []node
+}
+
+// Doc is a parsed chirp body.
+type Doc struct {
+	runes []rune
+	nodes []node
+}
+
+type parser struct {
+	runes []rune
+	// tags holds the hashtags and mentions of the whole body by start, so
+	// that the rules of the entities package decide what counts as one.
+	tags map[int]entities.Entity
+
+	// The next* tables hold, for every position, the first position at or
+	// after it where a delimiter of that kind could close, or len(runes).
+	// Closers are found with one lookup instead of a scan, which keeps
+	// parsing linear however many openers fail.
+	nextNewline, nextBacktick, nextBold []int
+	nextStar, nextUnderscore            []int
+	nextOpenBracket, nextCloseBracket   []int
+	// nextTargetEnd is where a link target ends: at ')', or at whitespace
+	// or a bracket, which make the link invalid. nextBareLinkEnd is where a
+	// bare link ends before its trailing punctuation is left out, and
+	// trimmedEnd where a text ending at a position ends without it.
+	nextTargetEnd, nextBareLinkEnd, trimmedEnd []int
+}
+
+func Parse(body string) *Doc {
+	p := &parser{runes: []rune(body), tags: map[int]entities.Entity{}}
+	for _, entity := range entities.Hashtags(body) {
+		p.tags[entity.Start] = entity
+	}
+	for _, entity := range entities.Mentions(body) {
+		p.tags[entity.Start] = entity
+	}
+	p.index()
+	return &Doc{runes: p.runes, nodes: p.parse(0, len(p.runes), false, false)}
+}
+
+func (p *parser) index() {
+	runes := p.runes
+	is := func(k int, r rune) bool { return k >= 0 && k < len(runes) && runes[k] == r }
+	p.nextNewline = nextIndex(runes, func(k int) bool { return runes[k] == '\n' })
+	p.nextBacktick = nextIndex(runes, func(k int) bool { return runes[k] == '`' })
+	p.nextBold = nextIndex(runes, func(k int) bool {
+		return runes[k] == '*' && is(k+1, '*') && k > 0 && !unicode.IsSpace(runes[k-1])
+	})
+	p.nextStar = nextIndex(runes, func(k int) bool { return p.italicCloses(k, '*', len(runes)) })
+	p.nextUnderscore = nextIndex(runes, func(k int) bool { return p.italicCloses(k, '_', len(runes)) })
+	p.nextOpenBracket = nextIndex(runes, func(k int) bool { return runes[k] == '[' })
+	p.nextCloseBracket = nextIndex(runes, func(k int) bool { return runes[k] == ']' })
+	p.nextTargetEnd = nextIndex(runes, func(k int) bool {
+		r := runes[k]
+		return r == ')' || r == '[' || r == ']' || unicode.IsSpace(r)
+	})
+	p.nextBareLinkEnd = nextIndex(runes, func(k int) bool {
+		return unicode.IsSpace(runes[k]) || strings.ContainsRune(`<>"`+"`", runes[k])
+	})
+	p.trimmedEnd = make([]int, len(runes)+1)
+	for k := 1; k <= len(runes); k++ {
+		p.trimmedEnd[k] = k
+		if strings.ContainsRune(trailingPunctuation, runes[k-1]) {
+			p.trimmedEnd[k] = p.trimmedEnd[k-1]
+		}
+	}
+}
+
+// nextIndex tabulates, for every position of runes and one past the end,
+// the first position at or after it where at holds.
+func nextIndex(runes []rune, at func(k int) bool) []int {
+	next := make([]int, len(runes)+1)
+	next[len(runes)] = len(runes)
+	for k := len(runes) - 1; k >= 0; k-- {
+		next[k] = next[k+1]
+		if at(k) {
+			next[k] = k
+		}
+	}
+	return next
+}
+
+func (p *parser) parse(start, end int, inBold, inItalic bool) []node {
+	var nodes []node
+	textStart := start
+	flush := func(i int) {
+		if i > textStart {
+			nodes = append(nodes, node{kind: kindText, start: textStart, end: i, value: string(p.runes[textStart:i])})
+		}
+	}
+	for i := start; i < end; {
+		n, ok := p.inline(i, end, inBold, inItalic)
+		if !ok {
+			i++
+			continue
+		}
+		flush(i)
+		nodes = append(nodes, n)
+		i = n.end
+		textStart = i
+	}
+	flush(end)
+	return nodes
+}
+
+// inline parses the markup starting at i, if any, without reaching past
+// end.
+func (p *parser) inline(i, end int, inBold, inItalic bool) (node, bool) {
+	r := p.runes[i]
+	switch {
+	case r == '`':
+		return p.code(i, end)
+	case r == '*' && i+1 < end && p.runes[i+1] == '*':
+		if inBold {
+			return node{}, false
+		}
+		return p.bold(i, end, inItalic)
+	case r == '*' || r == '_':
+		if inItalic {
+			return node{}, false
+		}
+		return p.italic(i, end, inBold)
+	case r == '[':
+		return p.link(i, end)
+	case r == 'h' || r == 'H':
+		return p.bareLink(i, end)
+	}
+	if entity, ok := p.tags[i]; ok && entity.End <= end {
+		return node{kind: entity.Type, start: i, end: entity.End, value: entity.Value}, true
+	}
+	return node{}, false
+}
+
+func (p *parser) code(i, end int) (node, bool) {
+	j := p.nextBacktick[i+1]
+	if j >= end || j == i+1 || p.nextNewline[i+1] < j {
+		return node{}, false
+	}
+	return node{kind: entities.TypeCode, start: i, end: j + 1, value: string(p.runes[i+1 : j])}, true
+}
+
+func (p *parser) bold(i, end int, inItalic bool) (node, bool) {
+	open := i + 2
+	if open >= end || unicode.IsSpace(p.runes[open]) {
+		return node{}, false
+	}
+	j := p.nextBold[open+1]
+	if j+1 >= end {
+		return node{}, false
+	}
+	return node{
+		kind:     entities.TypeBold,
+		start:    i,
+		end:      j + 2,
+		value:    string(p.runes[open:j]),
+		children: p.parse(open, j, true, inItalic),
+	}, true
+}
+
+// italic follows the usual emphasis rules closely enough that snake_case
+// words and lone asterisks stay text.
+func (p *parser) italic(i, end int, inBold bool) (node, bool) {
+	delim := p.runes[i]
+	open := i + 1
+	if open >= end || unicode.IsSpace(p.runes[open]) || p.runes[open] == delim {
+		return node{}, false
+	}
+	if delim == '_' && i > 0 && isWordRune(p.runes[i-1]) {
+		return node{}, false
+	}
+	next := p.nextStar
+	if delim == '_' {
+		next = p.nextUnderscore
+	}
+	j := next[open+1]
+	if j >= end-1 {
+		// the last position may close even if a delimiter follows past end
+		j = end - 1
+		if j <= open || !p.italicCloses(j, delim, end) {
+			return node{}, false
+		}
+	}
+	return node{
+		kind:     entities.TypeItalic,
+		start:    i,
+		end:      j + 1,
+		value:    string(p.runes[open:j]),
+		children: p.parse(open, j, inBold, true),
+	}, true
+}
+
+// italicCloses reports whether delim at k closes italic text in a parse
+// reaching up to end.
+func (p *parser) italicCloses(k int, delim rune, end int) bool {
+	runes := p.runes
+	if runes[k] != delim || k == 0 || unicode.IsSpace(runes[k-1]) || runes[k-1] == delim {
+		return false
+	}
+	if k+1 < end && runes[k+1] == delim {
+		return false
+	}
+	return delim != '_' || k+1 >= len(runes) || !isWordRune(runes[k+1])
+}
+
+// link parses [text](url). The text is shown as is, without formatting.
+func (p *parser) link(i, end int) (node, bool) {
+	close := p.nextCloseBracket[i+1]
+	if close >= end || p.nextOpenBracket[i+1] < close || p.nextNewline[i+1] < close {
+		return node{}, false
+	}
+	if close == i+1 || close+1 >= end || p.runes[close+1] != '(' {
+		return node{}, false
+	}
+	j := p.nextTargetEnd[close+2]
+	if j >= end || p.runes[j] != ')' {
+		return node{}, false
+	}
+	target := string(p.runes[close+2 : j])
+	if !validURL(target) {
+		return node{}, false
+	}
+	text := node{kind: kindText, start: i + 1, end: close, value: string(p.runes[i+1 : close])}
+	return node{kind: entities.TypeLink, start: i, end: j + 1, value: target, children: []node{text}}, true
+}
+
+// trailingPunctuation is left out of the end of bare links, so that a link
+// can end a sentence or sit inside formatting.
+const trailingPunctuation = ".,;:!?'\")]*_"
+
+// maxBareLinkLength is the longest bare link that is linked. Every "http://"
+// inside a link that failed to parse starts another try up to the same end,
+// so the limit keeps the work per try bounded.
+const maxBareLinkLength = 2048
+
+func (p *parser) bareLink(i, end int) (node, bool) {
+	if i > 0 && isWordRune(p.runes[i-1]) {
+		return node{}, false
+	}
+	rest := string(p.runes[i:min(end, i+8)])
+	if !strings.HasPrefix(strings.ToLower(rest), "http://") && !strings.HasPrefix(strings.ToLower(rest), "https://") {
+		return node{}, false
+	}
+	// the link starts with a letter, so trimming never passes i
+	j := p.trimmedEnd[min(p.nextBareLinkEnd[i], end)]
+	if j-i > maxBareLinkLength {
+		return node{}, false
+	}
+	target := string(p.runes[i:j])
+	if !validURL(target) {
+		return node{}, false
+	}
+	text := node{kind: kindText, start: i, end: j, value: target}
+	return node{kind: entities.TypeLink, start: i, end: j, value: target, children: []node{text}}, true
+}
+
+func validURL(s string) bool {
+	for _, r := range s {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return false
+		}
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// Entities lists the formatting, links, hashtags and mentions of the body
+// in order of their start. Mentions are not resolved.
+func (d *Doc) Entities() []entities.Entity {
+	var found []entities.Entity
+	var walk func(nodes []node)
+	walk = func(nodes []node) {
+		for _, n := range nodes {
+			if n.kind == kindText {
+				continue
+			}
+			found = append(found, entities.Entity{
+				Type:  n.kind,
+				Text:  string(d.runes[n.start:n.end]),
+				Value: n.value,
+				Start: n.start,
+				End:   n.end,
+			})
+			walk(n.children)
+		}
+	}
+	walk(d.nodes)
+	return found
+}
+
+// Links decides where mentions and hashtags point in the HTML. A mention
+// for which Mention reports false, or any entity whose function is nil, is
+// rendered as text.
+type Links struct {
+	Mention func(handle string) (string, bool)
+	Hashtag func(tag string) string
+}
+
+// HTML renders the body. All text is escaped and the only markup emitted is
+// strong, em, code, br and a elements with href, class and rel attributes,
+// where href is always an http(s) URL or a path given by links.
+func (d *Doc) HTML(links Links) string {
+	var b strings.Builder
+	d.render(&b, d.nodes, links)
+	return b.String()
+}
+
+func (d *Doc) render(b *strings.Builder, nodes []node, links Links) {
+	for _, n := range nodes {
+		raw := string(d.runes[n.start:n.end])
+		switch n.kind {
+		case kindText:
+			writeText(b, n.value)
+		case entities.TypeBold:
+			b.WriteString("<strong>")
+			d.render(b, n.children, links)
+			b.WriteString("</strong>")
+		case entities.TypeItalic:
+			b.WriteString("<em>")
+			d.render(b, n.children, links)
+			b.WriteString("</em>")
+		case entities.TypeCode:
+			b.WriteString("<code>")
+			b.WriteString(html.EscapeString(n.value))
+			b.WriteString("</code>")
+		case entities.TypeLink:
+			writeAnchor(b, n.value, "", `rel="nofollow noopener noreferrer"`)
+			d.render(b, n.children, links)
+			b.WriteString("</a>")
+		case entities.TypeMention:
+			href, ok := "", false
+			if links.Mention != nil {
+				href, ok = links.Mention(n.value)
+			}
+			if !ok {
+				writeText(b, raw)
+				continue
+			}
+			writeAnchor(b, href, "mention", "")
+			writeText(b, raw)
+			b.WriteString("</a>")
+		case entities.TypeHashtag:
+			if links.Hashtag == nil {
+				writeText(b, raw)
+				continue
+			}
+			writeAnchor(b, links.Hashtag(n.value), "hashtag", "")
+			writeText(b, raw)
+			b.WriteString("</a>")
+		}
+	}
+}
+
+func writeText(b *strings.Builder, s string) {
+	b.WriteString(strings.ReplaceAll(html.EscapeString(s), "\n", "<br>"))
+}
+
+func writeAnchor(b *strings.Builder, href, class, rel string) {
+	b.WriteString(`<a href="`)
+	b.WriteString(html.EscapeString(href))
+	b.WriteString(`"`)
+	if class != "" {
+		b.WriteString(` class="` + class + `"`)
+	}
+	if rel != "" {
+		b.WriteString(" " + rel)
+	}
+	b.WriteString(">")
+}
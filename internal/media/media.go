@@ -0,0 +1,314 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	ThumbnailSize = 320
+	maxPixels     = 25_000_000
+	// maxGIFPixels bounds the pixels of all frames of a GIF together, each
+	// counted at the size of the GIF's logical screen, which players draw
+	// every frame onto.
+	maxGIFPixels = 50_000_000
+	jpegQuality  = 90
+)
+
+var ErrUnsupportedType = errors.New("unsupported media type")
+
+// Processed is an upload that has been re-encoded without its metadata.
+type Processed struct {
+	ContentType          string
+	Data                 []byte
+	Width                int
+	Height               int
+	Thumbnail            []byte
+	ThumbnailContentType string
+}
+
+// Process checks what data really is by sniffing its content, then decodes
+// and re-encodes it. Re-encoding drops every metadata block, EXIF included;
+// the EXIF orientation of JPEGs is applied to the pixels first so photos keep
+// their rotation.
+func Process(data []byte) (Processed, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return Processed{}, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Processed{}, fmt.Errorf("invalid image: %w", err)
+	}
+	if config.Width*config.Height > maxPixels {
+		return Processed{}, fmt.Errorf("image is larger than %d pixels", maxPixels)
+	}
+
+	var out bytes.Buffer
+	var img image.Image
+	switch contentType {
+	case "image/jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Processed{}, fmt.Errorf("invalid image: %w", err)
+		}
+		img = applyOrientation(img, jpegOrientation(data))
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: jpegQuality})
+	case "image/png":
+		img, err = png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Processed{}, fmt.Errorf("invalid image: %w", err)
+		}
+		err = png.Encode(&out, img)
+	case "image/gif":
+		var pixels int
+		pixels, err = gifPixels(data)
+		if err != nil {
+			return Processed{}, fmt.Errorf("invalid image: %w", err)
+		}
+		if pixels > maxGIFPixels {
+			return Processed{}, fmt.Errorf("animation is larger than %d pixels", maxGIFPixels)
+		}
+		var anim *gif.GIF
+		anim, err = gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return Processed{}, fmt.Errorf("invalid image: %w", err)
+		}
+		// the first frame may cover only part of the logical screen
+		canvas := image.NewNRGBA(image.Rect(0, 0, anim.Config.Width, anim.Config.Height))
+		draw.Draw(canvas, anim.Image[0].Bounds(), anim.Image[0], anim.Image[0].Bounds().Min, draw.Src)
+		img = canvas
+		// EncodeAll only writes frames and the loop count, dropping
+		// comments and application extensions
+		err = gif.EncodeAll(&out, anim)
+	}
+	if err != nil {
+		return Processed{}, fmt.Errorf("error encoding image: %w", err)
+	}
+
+	processed := Processed{
+		ContentType: contentType,
+		Data:        out.Bytes(),
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+	}
+
+	var thumb bytes.Buffer
+	small := Thumbnail(img, ThumbnailSize)
+	if contentType == "image/jpeg" {
+		processed.ThumbnailContentType = "image/jpeg"
+		err = jpeg.Encode(&thumb, small, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		processed.ThumbnailContentType = "image/png"
+		err = png.Encode(&thumb, small)
+	}
+	if err != nil {
+		return Processed{}, fmt.Errorf("error encoding thumbnail: %w", err)
+	}
+	processed.Thumbnail = thumb.Bytes()
+	return processed, nil
+}
+
+// Thumbnail scales img down by area averaging so that neither side is longer
+// than size. Smaller images are returned unchanged.
+func Thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	tw, th := size, h*size/w
+	if h > w {
+		tw, th = w*size/h, size
+	}
+	tw, th = max(tw, 1), max(th, 1)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		sy0, sy1 := b.Min.Y+y*h/th, b.Min.Y+(y+1)*h/th
+		for x := 0; x < tw; x++ {
+			sx0, sx1 := b.Min.X+x*w/tw, b.Min.X+(x+1)*w/tw
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					c := color.NRGBAModel.Convert(img.At(sx, sy)).(color.NRGBA)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					bl += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(bl / n), A: uint8(a / n)})
+		}
+	}
+	return dst
+}
+
+// gifPixels adds up the pixels of every frame of a GIF by walking its blocks,
+// without decoding any image data. Frames count at least as the logical
+// screen, whatever the size of their own rectangle.
+func gifPixels(data []byte) (int, error) {
+	errTruncated := errors.New("truncated GIF")
+	if len(data) < 13 {
+		return 0, errTruncated
+	}
+	screen := int(binary.LittleEndian.Uint16(data[6:])) * int(binary.LittleEndian.Uint16(data[8:]))
+	i := 13 + colorTableSize(data[10])
+	pixels := 0
+	for {
+		if i >= len(data) {
+			return 0, errTruncated
+		}
+		switch data[i] {
+		case 0x21: // extension: label, then data sub-blocks
+			i += 2
+		case 0x2C: // image descriptor, color table, LZW code size, sub-blocks
+			if i+10 > len(data) {
+				return 0, errTruncated
+			}
+			w := int(binary.LittleEndian.Uint16(data[i+5:]))
+			h := int(binary.LittleEndian.Uint16(data[i+7:]))
+			pixels += max(w*h, screen)
+			i += 10 + colorTableSize(data[i+9]) + 1
+		case 0x3B: // trailer
+			return pixels, nil
+		default:
+			return 0, fmt.Errorf("unknown GIF block 0x%02x", data[i])
+		}
+		for {
+			if i >= len(data) {
+				return 0, errTruncated
+			}
+			size := int(data[i])
+			i += 1 + size
+			if size == 0 {
+				break
+			}
+		}
+	}
+}
+
+// colorTableSize is the size of the color table a GIF descriptor's packed
+// flags announce.
+func colorTableSize(flags byte) int {
+	if flags&0x80 == 0 {
+		return 0
+	}
+	return 3 << (flags&0x07 + 1)
+}
+
+// jpegOrientation returns the EXIF orientation (1-8) of a JPEG, or 1 when it
+// has none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return 1
+		}
+		segment := data[i+4 : end]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		i = end
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+	return 1
+}
+
+// applyOrientation transforms img so that it displays upright without its
+// EXIF orientation tag.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
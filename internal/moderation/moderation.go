@@ -0,0 +1,223 @@
+package moderation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+type Action string
+
+const (
+	ActionReject Action = "reject"
+	ActionMask   Action = "mask"
+	ActionFlag   Action = "flag"
+)
+
+const Mask = "****"
+
+// Decision records that a rule fired on a chirp body and what it did.
+type Decision struct {
+	Rule   string `json:"rule"`
+	Action Action `json:"action"`
+}
+
+// ContentFilter is one moderation rule. Filter returns the body to pass on to
+// the next filter and whether the rule fired.
+type ContentFilter interface {
+	Name() string
+	Filter(body string) (string, bool)
+	Action() Action
+}
+
+// Pipeline runs its filters in order. A rejecting filter stops the pipeline.
+type Pipeline []ContentFilter
+
+type Outcome struct {
+	Body      string
+	Decisions []Decision
+	Rejected  *Decision
+}
+
+func (o Outcome) Flagged() bool {
+	for _, d := range o.Decisions {
+		if d.Action == ActionFlag {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Pipeline) Run(body string) Outcome {
+	outcome := Outcome{Body: body}
+	for _, filter := range p {
+		filtered, fired := filter.Filter(outcome.Body)
+		if !fired {
+			continue
+		}
+		decision := Decision{Rule: filter.Name(), Action: filter.Action()}
+		outcome.Decisions = append(outcome.Decisions, decision)
+		switch decision.Action {
+		case ActionReject:
+			outcome.Rejected = &decision
+			return outcome
+		case ActionMask:
+			outcome.Body = filtered
+		}
+	}
+	return outcome
+}
+
+// WordList matches whole words case-insensitively and masks each one with
+// ****.
+type WordList struct {
+	RuleName   string
+	Words      []string
+	RuleAction Action
+}
+
+func (f WordList) Name() string   { return f.RuleName }
+func (f WordList) Action() Action { return f.RuleAction }
+
+func (f WordList) Filter(body string) (string, bool) {
+	blocked := make(map[string]bool, len(f.Words))
+	for _, word := range f.Words {
+		blocked[strings.ToLower(word)] = true
+	}
+
+	var out strings.Builder
+	fired := false
+	word := []rune{}
+	flush := func() {
+		if len(word) > 0 && blocked[strings.ToLower(string(word))] {
+			out.WriteString(Mask)
+			fired = true
+		} else {
+			out.WriteString(string(word))
+		}
+		word = word[:0]
+	}
+	for _, r := range body {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		out.WriteRune(r)
+	}
+	flush()
+	return out.String(), fired
+}
+
+// Regex matches a regular expression and masks each match with ****.
+type Regex struct {
+	RuleName   string
+	Pattern    *regexp.Regexp
+	RuleAction Action
+}
+
+func (f Regex) Name() string   { return f.RuleName }
+func (f Regex) Action() Action { return f.RuleAction }
+
+func (f Regex) Filter(body string) (string, bool) {
+	if !f.Pattern.MatchString(body) {
+		return body, false
+	}
+	return f.Pattern.ReplaceAllLiteralString(body, Mask), true
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"]+`)
+
+// LinkDomains matches links to any of Domains or their subdomains and masks
+// the whole link with ****.
+type LinkDomains struct {
+	RuleName   string
+	Domains    []string
+	RuleAction Action
+}
+
+func (f LinkDomains) Name() string   { return f.RuleName }
+func (f LinkDomains) Action() Action { return f.RuleAction }
+
+func (f LinkDomains) Filter(body string) (string, bool) {
+	fired := false
+	filtered := linkPattern.ReplaceAllStringFunc(body, func(link string) string {
+		u, err := url.Parse(link)
+		if err != nil {
+			return link
+		}
+		host := strings.ToLower(u.Hostname())
+		for _, domain := range f.Domains {
+			domain = strings.ToLower(domain)
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				fired = true
+				return Mask
+			}
+		}
+		return link
+	})
+	return filtered, fired
+}
+
+// Default is the classic Chirpy profanity filter.
+func Default() Pipeline {
+	return Pipeline{
+		WordList{RuleName: "profanity", Words: []string{"kerfuffle", "sharbert", "fornax"}, RuleAction: ActionMask},
+	}
+}
+
+type ruleConfig struct {
+	Type    string   `json:"type"`
+	Name    string   `json:"name"`
+	Action  Action   `json:"action"`
+	Words   []string `json:"words"`
+	Pattern string   `json:"pattern"`
+	Domains []string `json:"domains"`
+}
+
+// Load reads a pipeline from a JSON file holding an ordered array of rules:
+//
+//	[{"type": "words", "name": "profanity", "action": "mask", "words": ["fornax"]},
+//	 {"type": "regex", "name": "phone", "action": "flag", "pattern": "\\d{9}"},
+//	 {"type": "link_domains", "name": "spam", "action": "reject", "domains": ["spam.example"]}]
+func Load(path string) (Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading moderation rules: %w", err)
+	}
+	var rules []ruleConfig
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("error parsing moderation rules: %w", err)
+	}
+
+	pipeline := make(Pipeline, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("moderation rule %d has no name", i)
+		}
+		switch rule.Action {
+		case ActionReject, ActionMask, ActionFlag:
+		default:
+			return nil, fmt.Errorf("moderation rule %q has invalid action %q", rule.Name, rule.Action)
+		}
+		switch rule.Type {
+		case "words":
+			pipeline = append(pipeline, WordList{RuleName: rule.Name, Words: rule.Words, RuleAction: rule.Action})
+		case "regex":
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("moderation rule %q has invalid pattern: %w", rule.Name, err)
+			}
+			pipeline = append(pipeline, Regex{RuleName: rule.Name, Pattern: pattern, RuleAction: rule.Action})
+		case "link_domains":
+			pipeline = append(pipeline, LinkDomains{RuleName: rule.Name, Domains: rule.Domains, RuleAction: rule.Action})
+		default:
+			return nil, fmt.Errorf("moderation rule %q has unknown type %q", rule.Name, rule.Type)
+		}
+	}
+	return pipeline, nil
+}
@@ -0,0 +1,143 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Cursor is the keyset position a page starts after. Clients only ever see it
+// as an opaque string.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+	Score     float64   `json:"s,omitempty"`
+	Key       string    `json:"k,omitempty"`
+	Backward  bool      `json:"b,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	if c.ID == uuid.Nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// Params holds the parsed `limit` and `cursor` query parameters. Cursor is nil
+// when the first page is requested.
+type Params struct {
+	Limit  int
+	Cursor *Cursor
+}
+
+func ParseParams(query url.Values) (Params, error) {
+	params := Params{Limit: DefaultLimit}
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return Params{}, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+		params.Limit = limit
+	}
+	if s := query.Get("cursor"); s != "" {
+		cursor, err := DecodeCursor(s)
+		if err != nil {
+			return Params{}, err
+		}
+		params.Cursor = &cursor
+	}
+	return params, nil
+}
+
+// Page is the envelope returned by paginated endpoints.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// SetLinkHeader writes an RFC 8288 Link header pointing at the next and
+// previous pages, keeping every other query parameter of the request.
+func SetLinkHeader(w http.ResponseWriter, r *http.Request, nextCursor, prevCursor string) {
+	var links []string
+	for _, link := range []struct{ rel, cursor string }{{"next", nextCursor}, {"prev", prevCursor}} {
+		if link.cursor == "" {
+			continue
+		}
+		u := *r.URL
+		query := u.Query()
+		query.Set("cursor", link.cursor)
+		u.RawQuery = query.Encode()
+		links = append(links, fmt.Sprintf("<%s>; rel=\"%s\"", u.RequestURI(), link.rel))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// Descending reports whether the rows for this request have to be fetched in
+// descending keyset order: a forward page of a descending listing, or a
+// backward page of an ascending one.
+func (p Params) Descending(sortDesc bool) bool {
+	backward := p.Cursor != nil && p.Cursor.Backward
+	return sortDesc != backward
+}
+
+// Window turns rows fetched with Limit+1 in query order into a page in display
+// order, along with the cursors for its neighbours.
+func Window[T any](rows []T, p Params, key func(T) Cursor) (page []T, nextCursor, prevCursor string) {
+	backward := p.Cursor != nil && p.Cursor.Backward
+	hasMore := len(rows) > p.Limit
+	if hasMore {
+		rows = rows[:p.Limit]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if len(rows) == 0 {
+		return rows, "", ""
+	}
+
+	hasNext, hasPrev := hasMore, p.Cursor != nil
+	if backward {
+		hasNext, hasPrev = true, hasMore
+	}
+	if hasNext {
+		next := key(rows[len(rows)-1])
+		next.Backward = false
+		nextCursor = next.Encode()
+	}
+	if hasPrev {
+		prev := key(rows[0])
+		prev.Backward = true
+		prevCursor = prev.Encode()
+	}
+	return rows, nextCursor, prevCursor
+}
@@ -0,0 +1,68 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ParseQuery turns user search input into a Postgres tsquery expression.
+// Quoted text becomes a phrase query, a trailing * makes a term a prefix
+// match, and every other term is required. Terms are reduced to letters and
+// digits, so the result is always valid tsquery syntax.
+func ParseQuery(input string) (string, error) {
+	var terms []string
+	for i, part := range strings.Split(input, `"`) {
+		if i%2 == 1 {
+			if phrase := phraseTerm(part); phrase != "" {
+				terms = append(terms, phrase)
+			}
+			continue
+		}
+		for _, field := range strings.Fields(part) {
+			if term := wordTerm(field); term != "" {
+				terms = append(terms, term)
+			}
+		}
+	}
+	if len(terms) == 0 {
+		return "", fmt.Errorf("search query has no searchable terms")
+	}
+	return strings.Join(terms, " & "), nil
+}
+
+func phraseTerm(phrase string) string {
+	var words []string
+	for _, field := range strings.Fields(phrase) {
+		if word := clean(field); word != "" {
+			words = append(words, word)
+		}
+	}
+	switch len(words) {
+	case 0:
+		return ""
+	case 1:
+		return words[0]
+	}
+	return "(" + strings.Join(words, " <-> ") + ")"
+}
+
+func wordTerm(field string) string {
+	word := clean(field)
+	if word == "" {
+		return ""
+	}
+	if strings.HasSuffix(field, "*") {
+		return word + ":*"
+	}
+	return word
+}
+
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
@@ -0,0 +1,43 @@
+package textlen
+
+import (
+	"regexp"
+
+	"github.com/rivo/uniseg"
+)
+
+// URLWeight is what every link counts for, however long it is.
+const URLWeight = 23
+
+// MaxBytesPerChar bounds the size of a body against its length limit: a body
+// limited to n characters may take at most n*MaxBytesPerChar bytes. Since
+// links and grapheme clusters can be arbitrarily long, the character count
+// alone does not bound the work a body causes.
+const MaxBytesPerChar = 8
+
+var urlPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"]+`)
+
+// ChirpLength measures body the way users see it: in grapheme clusters, with
+// each URL counted as URLWeight.
+func ChirpLength(body string) int {
+	length := 0
+	last := 0
+	for _, loc := range urlPattern.FindAllStringIndex(body, -1) {
+		length += Graphemes(body[last:loc[0]]) + URLWeight
+		last = loc[1]
+	}
+	return length + Graphemes(body[last:])
+}
+
+// Fits reports whether body is within limit characters, and within the
+// byte ceiling that limit implies.
+func Fits(body string, limit int) bool {
+	return len(body) <= limit*MaxBytesPerChar && ChirpLength(body) <= limit
+}
+
+// Graphemes counts the user-perceived characters in s, the extended
+// grapheme clusters of UAX #29: combining marks, emoji modifiers and ZWJ
+// sequences, flags and Hangul syllables all count once.
+func Graphemes(s string) int {
+	return uniseg.GraphemeClusterCount(s)
+}
@@ -0,0 +1,80 @@
+package timeline
+
+import (
+	"bytes"
+	"container/heap"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entry is a chirp in a home timeline, keyed by (CreatedAt, ChirpID) like
+// the timeline is paged.
+type Entry struct {
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+// Less reports whether a sorts before b in ascending (created_at, id) order,
+// comparing IDs bytewise like Postgres compares UUIDs.
+func Less(a, b Entry) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return bytes.Compare(a.ChirpID[:], b.ChirpID[:]) < 0
+}
+
+// Merge combines sources, each already sorted in timeline order (newest
+// first when descending), into at most limit entries in that order. A chirp
+// found in several sources is kept once.
+func Merge(limit int, descending bool, sources ...[]Entry) []Entry {
+	h := &heads{descending: descending}
+	for _, source := range sources {
+		if len(source) > 0 {
+			h.sources = append(h.sources, source)
+		}
+	}
+	heap.Init(h)
+
+	merged := make([]Entry, 0, limit)
+	for h.Len() > 0 && len(merged) < limit {
+		source := h.sources[0]
+		// copies of a chirp share their key, so they come out back to back
+		if n := len(merged); n == 0 || merged[n-1].ChirpID != source[0].ChirpID {
+			merged = append(merged, source[0])
+		}
+		if len(source) > 1 {
+			h.sources[0] = source[1:]
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return merged
+}
+
+// heads is a heap of the unmerged rest of each source, ordered by its first
+// entry.
+type heads struct {
+	sources    [][]Entry
+	descending bool
+}
+
+func (h *heads) Len() int { return len(h.sources) }
+
+func (h *heads) Less(i, j int) bool {
+	if h.descending {
+		return Less(h.sources[j][0], h.sources[i][0])
+	}
+	return Less(h.sources[i][0], h.sources[j][0])
+}
+
+func (h *heads) Swap(i, j int) { h.sources[i], h.sources[j] = h.sources[j], h.sources[i] }
+
+func (h *heads) Push(x any) { h.sources = append(h.sources, x.([]Entry)) }
+
+func (h *heads) Pop() any {
+	last := h.sources[len(h.sources)-1]
+	h.sources = h.sources[:len(h.sources)-1]
+	return last
+}
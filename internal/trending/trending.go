@@ -0,0 +1,145 @@
+package trending
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Window is a sliding time window trends are computed over. Older uses count
+// less, halving in weight every HalfLife.
+type Window struct {
+	Name     string
+	Length   time.Duration
+	HalfLife time.Duration
+}
+
+var DefaultWindows = []Window{
+	{Name: "1h", Length: time.Hour, HalfLife: 15 * time.Minute},
+	{Name: "24h", Length: 24 * time.Hour, HalfLife: 6 * time.Hour},
+	{Name: "7d", Length: 7 * 24 * time.Hour, HalfLife: 24 * time.Hour},
+}
+
+const bucketsPerWindow = 48
+
+// Bucket returns the size of the age buckets uses are counted in for w.
+func (w Window) Bucket() time.Duration {
+	return w.Length / bucketsPerWindow
+}
+
+// Usage is how many times Tag was used in the age bucket AgeBucket, counting
+// back from now in units of the window's Bucket.
+type Usage struct {
+	Tag       string
+	AgeBucket int64
+	Uses      int64
+}
+
+type Trend struct {
+	Tag   string  `json:"tag"`
+	Score float64 `json:"score"`
+	Uses  int64   `json:"uses"`
+}
+
+// Score ranks tags by their decayed use count within the window, best first.
+func Score(usages []Usage, w Window, limit int) []Trend {
+	bucket := w.Bucket()
+	byTag := map[string]*Trend{}
+	for _, u := range usages {
+		age := time.Duration(u.AgeBucket)*bucket + bucket/2
+		if u.AgeBucket < 0 || age > w.Length {
+			continue
+		}
+		trend, ok := byTag[u.Tag]
+		if !ok {
+			trend = &Trend{Tag: u.Tag}
+			byTag[u.Tag] = trend
+		}
+		trend.Score += float64(u.Uses) * math.Pow(0.5, age.Seconds()/w.HalfLife.Seconds())
+		trend.Uses += u.Uses
+	}
+
+	trends := make([]Trend, 0, len(byTag))
+	for _, trend := range byTag {
+		trends = append(trends, *trend)
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Score != trends[j].Score {
+			return trends[i].Score > trends[j].Score
+		}
+		return trends[i].Tag < trends[j].Tag
+	})
+	if len(trends) > limit {
+		trends = trends[:limit]
+	}
+	return trends
+}
+
+// Store holds the latest computed trends so requests never recompute them.
+type Store struct {
+	mu         sync.RWMutex
+	trends     map[string][]Trend
+	computedAt time.Time
+}
+
+func NewStore() *Store {
+	return &Store{trends: map[string][]Trend{}}
+}
+
+func (s *Store) Get(window string) ([]Trend, time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	trends, ok := s.trends[window]
+	return trends, s.computedAt, ok
+}
+
+func (s *Store) set(trends map[string][]Trend) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trends = trends
+	s.computedAt = time.Now().UTC()
+}
+
+// FetchFunc loads tag usage for a window, bucketed by age.
+type FetchFunc func(ctx context.Context, w Window) ([]Usage, error)
+
+// MaxTrends is how many tags are kept per window.
+const MaxTrends = 50
+
+// Run recomputes the trends for every window each interval until ctx is done.
+func Run(ctx context.Context, store *Store, windows []Window, interval time.Duration, fetch FetchFunc) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		computed := make(map[string][]Trend, len(windows))
+		for _, w := range windows {
+			usages, err := fetch(ctx, w)
+			if err != nil {
+				log.Printf("Error computing trending tags for %s: %s", w.Name, err)
+				continue
+			}
+			computed[w.Name] = Score(usages, w, MaxTrends)
+		}
+		if len(computed) == len(windows) {
+			store.set(computed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func IsWindow(name string) bool {
+	for _, w := range DefaultWindows {
+		if w.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,305 @@
+// Package twitter reads the tweets out of the archive Twitter/X lets users
+// download from their account settings.
+package twitter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxTweetsSize bounds how much of the tweets files Open will read, over
+// all parts together.
+const maxTweetsSize = 256 << 20
+
+// maxAccountSize bounds data/account.js, which holds a single account.
+const maxAccountSize = 1 << 20
+
+// MaxTweets is the most tweets an archive may hold. Archives are imported
+// within one request.
+const MaxTweets = 10000
+
+var (
+	ErrInvalid  = errors.New("not a Twitter archive")
+	ErrTooLarge = errors.New("archive file is too large")
+	ErrTooMany  = fmt.Errorf("archive has more than %d tweets", MaxTweets)
+)
+
+// Archives name the tweets file data/tweets.js, or data/tweet.js before
+// 2022, and split large histories into -partN files.
+var tweetsFile = regexp.MustCompile(`^data/tweets?(-part\d+)?\.js$`)
+
+var retweetPrefix = regexp.MustCompile(`^RT @(\w+):`)
+
+type Tweet struct {
+	ID        string
+	CreatedAt time.Time
+	// Text is the tweet as it was shown, with t.co links expanded and the
+	// links to attached media removed.
+	Text        string
+	InReplyToID string
+	// RetweetOf is the screen name of the retweeted account, for retweets.
+	RetweetOf string
+	// RetweetedID is the ID of the retweeted tweet, when the archive
+	// records it or holds the retweeted tweet itself.
+	RetweetedID string
+	Media       []Media
+}
+
+// Media is a photo, GIF or video attached to a tweet. File is its path in
+// the archive, empty when the archive left it out.
+type Media struct {
+	Type string
+	File string
+}
+
+type Archive struct {
+	// Tweets are ordered oldest first.
+	Tweets []Tweet
+	files  map[string]*zip.File
+}
+
+type rawTweet struct {
+	IDStr                string `json:"id_str"`
+	CreatedAt            string `json:"created_at"`
+	FullText             string `json:"full_text"`
+	InReplyToStatusIDStr string `json:"in_reply_to_status_id_str"`
+	Entities             struct {
+		URLs []struct {
+			URL         string `json:"url"`
+			ExpandedURL string `json:"expanded_url"`
+		} `json:"urls"`
+		Media []rawMedia `json:"media"`
+	} `json:"entities"`
+	ExtendedEntities struct {
+		Media []rawMedia `json:"media"`
+	} `json:"extended_entities"`
+	RetweetedStatus *struct {
+		IDStr string `json:"id_str"`
+	} `json:"retweeted_status"`
+}
+
+type rawMedia struct {
+	URL           string `json:"url"`
+	MediaURLHTTPS string `json:"media_url_https"`
+	Type          string `json:"type"`
+}
+
+func Open(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	a := &Archive{files: make(map[string]*zip.File, len(zr.File))}
+	var parts []string
+	for _, f := range zr.File {
+		a.files[f.Name] = f
+		if tweetsFile.MatchString(f.Name) {
+			parts = append(parts, f.Name)
+		}
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("%w: missing data/tweets.js", ErrInvalid)
+	}
+
+	budget := int64(maxTweetsSize)
+	for _, name := range parts {
+		data, err := a.read(name, budget)
+		if err != nil {
+			return nil, err
+		}
+		budget -= int64(len(data))
+		raw, err := parseTweetsFile(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed %s", ErrInvalid, name)
+		}
+		if len(a.Tweets)+len(raw) > MaxTweets {
+			return nil, ErrTooMany
+		}
+		for _, rt := range raw {
+			tweet, err := a.convert(rt)
+			if err != nil {
+				return nil, fmt.Errorf("%w: tweet %s: %v", ErrInvalid, rt.IDStr, err)
+			}
+			a.Tweets = append(a.Tweets, tweet)
+		}
+	}
+	sort.SliceStable(a.Tweets, func(i, j int) bool {
+		return a.Tweets[i].CreatedAt.Before(a.Tweets[j].CreatedAt)
+	})
+	if err := a.findRetweeted(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// findRetweeted sets RetweetedID on the retweets of the account's own
+// tweets that the archive did not link to their tweet, by finding the tweet
+// with the retweeted text. Archives leave retweeted_status out, but a
+// retweet of one's own tweet reads "RT @username: " and the tweet's text.
+func (a *Archive) findRetweeted() error {
+	username, err := a.username()
+	if err != nil || username == "" {
+		return err
+	}
+	byText := map[string]string{}
+	for _, tweet := range a.Tweets {
+		if _, ok := byText[tweet.Text]; !ok && tweet.RetweetOf == "" {
+			byText[tweet.Text] = tweet.ID
+		}
+	}
+	for i, tweet := range a.Tweets {
+		if tweet.RetweetedID != "" || !strings.EqualFold(tweet.RetweetOf, username) {
+			continue
+		}
+		text := strings.TrimSpace(tweet.Text[len(retweetPrefix.FindString(tweet.Text)):])
+		a.Tweets[i].RetweetedID = byText[text]
+	}
+	return nil
+}
+
+// username reads the account's screen name from data/account.js, or
+// returns "" when the archive has no account file.
+func (a *Archive) username() (string, error) {
+	if _, ok := a.files["data/account.js"]; !ok {
+		return "", nil
+	}
+	data, err := a.read("data/account.js", maxAccountSize)
+	if err != nil {
+		return "", err
+	}
+	i := bytes.IndexByte(data, '=')
+	if i < 0 {
+		return "", fmt.Errorf("%w: malformed data/account.js", ErrInvalid)
+	}
+	var entries []struct {
+		Account struct {
+			Username string `json:"username"`
+		} `json:"account"`
+	}
+	if err := json.Unmarshal(data[i+1:], &entries); err != nil || len(entries) == 0 {
+		return "", fmt.Errorf("%w: malformed data/account.js", ErrInvalid)
+	}
+	return entries[0].Account.Username, nil
+}
+
+// parseTweetsFile reads a JavaScript file of the form
+// `window.YTD.tweets.part0 = [...]`. Newer archives wrap every tweet in a
+// {"tweet": ...} object.
+func parseTweetsFile(data []byte) ([]rawTweet, error) {
+	i := bytes.IndexByte(data, '=')
+	if i < 0 {
+		return nil, errors.New("missing assignment")
+	}
+	var entries []struct {
+		Tweet *rawTweet `json:"tweet"`
+		rawTweet
+	}
+	if err := json.Unmarshal(data[i+1:], &entries); err != nil {
+		return nil, err
+	}
+	tweets := make([]rawTweet, len(entries))
+	for i, entry := range entries {
+		tweets[i] = entry.rawTweet
+		if entry.Tweet != nil {
+			tweets[i] = *entry.Tweet
+		}
+	}
+	return tweets, nil
+}
+
+func (a *Archive) convert(rt rawTweet) (Tweet, error) {
+	createdAt, err := time.Parse(time.RubyDate, rt.CreatedAt)
+	if err != nil {
+		return Tweet{}, errors.New("invalid created_at")
+	}
+	if rt.IDStr == "" {
+		return Tweet{}, errors.New("missing id_str")
+	}
+	tweet := Tweet{
+		ID:          rt.IDStr,
+		CreatedAt:   createdAt.UTC(),
+		InReplyToID: rt.InReplyToStatusIDStr,
+	}
+
+	text := rt.FullText
+	for _, u := range rt.Entities.URLs {
+		if u.URL != "" && u.ExpandedURL != "" {
+			text = strings.ReplaceAll(text, u.URL, u.ExpandedURL)
+		}
+	}
+	media := rt.ExtendedEntities.Media
+	if len(media) == 0 {
+		media = rt.Entities.Media
+	}
+	for _, m := range media {
+		if m.URL != "" {
+			text = strings.ReplaceAll(text, m.URL, "")
+		}
+		tweet.Media = append(tweet.Media, Media{Type: m.Type, File: a.mediaFile(rt.IDStr, m.MediaURLHTTPS)})
+	}
+	tweet.Text = strings.TrimSpace(html.UnescapeString(text))
+
+	if m := retweetPrefix.FindStringSubmatch(tweet.Text); m != nil {
+		tweet.RetweetOf = m[1]
+		if rt.RetweetedStatus != nil {
+			tweet.RetweetedID = rt.RetweetedStatus.IDStr
+		}
+	}
+	return tweet, nil
+}
+
+// mediaFile finds the archived copy of a media URL, which is saved as
+// <tweet id>-<file name>.
+func (a *Archive) mediaFile(tweetID, mediaURL string) string {
+	if mediaURL == "" {
+		return ""
+	}
+	name := tweetID + "-" + path.Base(mediaURL)
+	for _, dir := range []string{"data/tweets_media/", "data/tweet_media/"} {
+		if _, ok := a.files[dir+name]; ok {
+			return dir + name
+		}
+	}
+	return ""
+}
+
+// ReadMedia returns the contents of a file named in Media, reading at most
+// limit bytes.
+func (a *Archive) ReadMedia(file string, limit int64) ([]byte, error) {
+	if !strings.HasPrefix(file, "data/tweets_media/") && !strings.HasPrefix(file, "data/tweet_media/") {
+		return nil, ErrInvalid
+	}
+	return a.read(file, limit)
+}
+
+// read never trusts the sizes recorded in the ZIP, so a compressed entry
+// cannot expand past limit.
+func (a *Archive) read(name string, limit int64) ([]byte, error) {
+	f, ok := a.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalid, name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: %s", ErrTooLarge, name)
+	}
+	return data, nil
+}
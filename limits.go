@@ -0,0 +1,47 @@
+package main
+
+import (
+	"GoServer/internal/textlen"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type Limits struct {
+	Tier            string `json:"tier"`
+	MaxChirpLength  int    `json:"max_chirp_length"`
+	URLLength       int    `json:"url_length"`
+	MaxMedia        int    `json:"max_media"`
+	MaxMediaBytes   int64  `json:"max_media_bytes"`
+	MaxAltTextChars int    `json:"max_alt_text_length"`
+}
+
+// getLimits tells clients how chirps are measured so their counters agree
+// with the server. Anonymous callers get the limits of a regular account.
+func (cfg *apiConfig) getLimits(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.optionalUserID(w, r)
+	if !ok {
+		return
+	}
+
+	limits := Limits{
+		Tier:            "regular",
+		MaxChirpLength:  cfg.ChirpMaxLength,
+		URLLength:       textlen.URLWeight,
+		MaxMedia:        maxChirpMedia,
+		MaxMediaBytes:   cfg.MediaMaxBytes,
+		MaxAltTextChars: maxAltText,
+	}
+	if userID != uuid.Nil {
+		user, err := cfg.DB.GetUserByID(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error retrieving user", err)
+			return
+		}
+		if user.IsChirpyRed {
+			limits.Tier = "chirpy_red"
+			limits.MaxChirpLength = cfg.ChirpMaxLengthRed
+		}
+	}
+	respondWithJSON(w, http.StatusOK, limits)
+}
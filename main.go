@@ -1,12 +1,19 @@
 package main
 
 import (
+	"GoServer/internal/blobstore"
 	"GoServer/internal/database"
+	"GoServer/internal/entities"
+	"GoServer/internal/moderation"
+	"GoServer/internal/pagination"
+	"GoServer/internal/trending"
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"sync/atomic"
 	"time"
 
@@ -16,11 +23,28 @@ import (
 )
 
 type apiConfig struct {
-	fileserverHits atomic.Int32
-	DB             *database.Queries
-	Platform       string
-	Secret         string
-	PolkaKey       string
+	fileserverHits  atomic.Int32
+	DB              *database.Queries
+	DBConn          *sql.DB
+	Platform        string
+	Secret          string
+	PolkaKey        string
+	ChirpEditWindow time.Duration
+	ChirpMaxEdits   int
+	Trends          *trending.Store
+	Moderation      moderation.Pipeline
+	Blobs           blobstore.BlobStore
+	MediaMaxBytes   int64
+	ChirpRetention  time.Duration
+	// ChirpMaxLength and ChirpMaxLengthRed are the chirp length limits of
+	// regular and Chirpy Red accounts.
+	ChirpMaxLength    int
+	ChirpMaxLengthRed int
+	// TimelineMaxLength is how many entries a stored home timeline keeps.
+	// Chirps of accounts with more than TimelineFanoutMaxFollowers
+	// followers are not fanned out but merged into timelines on read.
+	TimelineMaxLength          int
+	TimelineFanoutMaxFollowers int
 }
 
 type User struct {
@@ -32,14 +56,106 @@ type User struct {
 	AccessToken    string    `json:"token"`
 	RefreshToken   string    `json:"refresh_token"`
 	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	Handle         string    `json:"handle,omitempty"`
+	// ExpandContentWarnings shows chirps behind content warnings expanded.
+	ExpandContentWarnings bool `json:"expand_content_warnings"`
 }
 
 type Chirp struct {
 	ID        uuid.UUID `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserId    uuid.UUID `json:"user_id"`
+	// Body is the raw source, markup included, as it is edited.
+	Body string `json:"body"`
+	// BodyHTML is Body rendered with its markup, safe to insert as HTML.
+	BodyHTML       string            `json:"body_html"`
+	UserId         uuid.UUID         `json:"user_id"`
+	EditCount      int32             `json:"edit_count"`
+	ParentID       uuid.NullUUID     `json:"parent_id"`
+	ConversationID uuid.UUID         `json:"conversation_id"`
+	ReplyCount     int64             `json:"reply_count"`
+	LikeCount      int64             `json:"like_count"`
+	LikedByMe      bool              `json:"liked_by_me"`
+	Bookmarked     bool              `json:"bookmarked"`
+	RechirpCount   int64             `json:"rechirp_count"`
+	QuoteCount     int64             `json:"quote_count"`
+	RechirpOf      *Chirp            `json:"rechirp_of,omitempty"`
+	QuoteOf        *Chirp            `json:"quote_of,omitempty"`
+	Entities       []entities.Entity `json:"entities"`
+	Media          []ChirpMedia      `json:"media"`
+	Poll           *Poll             `json:"poll,omitempty"`
+	// Moderation lists the rules that fired when the body was last written.
+	// It is only set on create and edit responses.
+	Moderation []moderation.Decision `json:"moderation,omitempty"`
+	Deleted    bool                  `json:"deleted"`
+	// TakenDown marks tombstones of chirps removed by a moderator, whose
+	// reason is shown in DeletionReason.
+	TakenDown      bool   `json:"taken_down,omitempty"`
+	DeletionReason string `json:"deletion_reason,omitempty"`
+	// PublishAt is set while the chirp is scheduled and only visible to its
+	// author.
+	PublishAt  *time.Time `json:"publish_at,omitempty"`
+	Visibility string     `json:"visibility,omitempty"`
+	// ContentWarning hides the body behind a label until it is expanded.
+	// Sensitive chirps have their media blurred.
+	ContentWarning string `json:"content_warning,omitempty"`
+	Sensitive      bool   `json:"sensitive"`
+	// Collapsed tells clients to show only the content warning at first,
+	// which depends on the viewer's preference.
+	Collapsed bool `json:"collapsed"`
+}
+
+func chirpFromDB(chirp database.Chirp) Chirp {
+	conversationID := chirp.ID
+	if chirp.RootID.Valid {
+		conversationID = chirp.RootID.UUID
+	}
+	response := Chirp{
+		ID:             chirp.ID,
+		CreatedAt:      chirp.CreatedAt,
+		UpdatedAt:      chirp.UpdatedAt,
+		Body:           chirp.Body,
+		UserId:         chirp.UserID,
+		EditCount:      chirp.EditCount,
+		ParentID:       chirp.ParentID,
+		ConversationID: conversationID,
+		Deleted:        chirp.DeletedAt.Valid,
+		Visibility:     chirp.Visibility,
+		ContentWarning: chirp.ContentWarning.String,
+		Sensitive:      chirp.Sensitive,
+	}
+	if chirp.PublishAt.Valid {
+		response.PublishAt = &chirp.PublishAt.Time
+	}
+	if chirp.DeletedAt.Valid {
+		// tombstones keep their body until they are purged, for restores
+		response.Body = ""
+		response.ContentWarning = ""
+		response.Sensitive = false
+		if chirp.DeletionKind.String != deletionByAuthor {
+			response.TakenDown = true
+			response.DeletionReason = chirp.DeletionReason.String
+		}
+	}
+	return response
+}
+
+func chirpCursor(chirp database.Chirp) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: chirp.CreatedAt, ID: chirp.ID}
+}
+
+// intEnv reads a positive integer from the environment, falling back to def
+// when the variable is unset.
+func intEnv(name string, def int) (int, error) {
+	s := os.Getenv(name)
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q", name, s)
+	}
+	return n, nil
 }
 
 func main() {
@@ -49,16 +165,136 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	var editWindow time.Duration
+	if s := os.Getenv("CHIRP_EDIT_WINDOW"); s != "" {
+		editWindow, err = time.ParseDuration(s)
+		if err != nil {
+			log.Fatalf("invalid CHIRP_EDIT_WINDOW: %v", err)
+		}
+	}
+	var maxEdits int
+	if s := os.Getenv("CHIRP_MAX_EDITS"); s != "" {
+		maxEdits, err = strconv.Atoi(s)
+		if err != nil {
+			log.Fatalf("invalid CHIRP_MAX_EDITS: %v", err)
+		}
+	}
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "./media"
+	}
+	blobs, err := blobstore.NewLocal(mediaDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var mediaMaxBytes int64 = 5 << 20
+	if s := os.Getenv("MEDIA_MAX_BYTES"); s != "" {
+		mediaMaxBytes, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || mediaMaxBytes <= 0 {
+			log.Fatalf("invalid MEDIA_MAX_BYTES: %q", s)
+		}
+	}
+	retention := 30 * 24 * time.Hour
+	if s := os.Getenv("CHIRP_RETENTION"); s != "" {
+		retention, err = time.ParseDuration(s)
+		if err != nil || retention <= 0 {
+			log.Fatalf("invalid CHIRP_RETENTION: %q", s)
+		}
+	}
+	maxLength, err := intEnv("CHIRP_MAX_LENGTH", 140)
+	if err != nil {
+		log.Fatal(err)
+	}
+	maxLengthRed, err := intEnv("CHIRP_MAX_LENGTH_RED", 1000)
+	if err != nil {
+		log.Fatal(err)
+	}
+	timelineMaxLength, err := intEnv("TIMELINE_MAX_LENGTH", 800)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fanoutMaxFollowers, err := intEnv("TIMELINE_FANOUT_MAX_FOLLOWERS", 10000)
+	if err != nil {
+		log.Fatal(err)
+	}
 	var apiCfg apiConfig = apiConfig{
-		fileserverHits: atomic.Int32{},
-		DB:             database.New(db),
-		Platform:       os.Getenv("PLATFORM"),
-		Secret:         os.Getenv("SECRET_KEY"),
-		PolkaKey:       os.Getenv("POLKA_KEY"),
+		fileserverHits:             atomic.Int32{},
+		DB:                         database.New(db),
+		DBConn:                     db,
+		Platform:                   os.Getenv("PLATFORM"),
+		Secret:                     os.Getenv("SECRET_KEY"),
+		PolkaKey:                   os.Getenv("POLKA_KEY"),
+		ChirpEditWindow:            editWindow,
+		ChirpMaxEdits:              maxEdits,
+		Trends:                     trending.NewStore(),
+		Moderation:                 moderation.Default(),
+		Blobs:                      blobs,
+		MediaMaxBytes:              mediaMaxBytes,
+		ChirpRetention:             retention,
+		ChirpMaxLength:             maxLength,
+		ChirpMaxLengthRed:          maxLengthRed,
+		TimelineMaxLength:          timelineMaxLength,
+		TimelineFanoutMaxFollowers: fanoutMaxFollowers,
+	}
+	if path := os.Getenv("MODERATION_RULES"); path != "" {
+		apiCfg.Moderation, err = moderation.Load(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	trendingInterval := time.Minute
+	if s := os.Getenv("TRENDING_INTERVAL"); s != "" {
+		trendingInterval, err = time.ParseDuration(s)
+		if err != nil || trendingInterval <= 0 {
+			log.Fatalf("invalid TRENDING_INTERVAL: %q", s)
+		}
+	}
+	go trending.Run(context.Background(), apiCfg.Trends, trending.DefaultWindows, trendingInterval, apiCfg.fetchTagUsage)
+	publishInterval := 10 * time.Second
+	if s := os.Getenv("PUBLISH_INTERVAL"); s != "" {
+		publishInterval, err = time.ParseDuration(s)
+		if err != nil || publishInterval <= 0 {
+			log.Fatalf("invalid PUBLISH_INTERVAL: %q", s)
+		}
 	}
+	go apiCfg.runPublisher(context.Background(), publishInterval)
+	purgeInterval := time.Hour
+	if s := os.Getenv("PURGE_INTERVAL"); s != "" {
+		purgeInterval, err = time.ParseDuration(s)
+		if err != nil || purgeInterval <= 0 {
+			log.Fatalf("invalid PURGE_INTERVAL: %q", s)
+		}
+	}
+	go apiCfg.runPurger(context.Background(), purgeInterval)
+	exportInterval := 10 * time.Second
+	if s := os.Getenv("EXPORT_INTERVAL"); s != "" {
+		exportInterval, err = time.ParseDuration(s)
+		if err != nil || exportInterval <= 0 {
+			log.Fatalf("invalid EXPORT_INTERVAL: %q", s)
+		}
+	}
+	go apiCfg.runExporter(context.Background(), exportInterval)
+	fanoutInterval := 2 * time.Second
+	if s := os.Getenv("FANOUT_INTERVAL"); s != "" {
+		fanoutInterval, err = time.ParseDuration(s)
+		if err != nil || fanoutInterval <= 0 {
+			log.Fatalf("invalid FANOUT_INTERVAL: %q", s)
+		}
+	}
+	go apiCfg.runFanout(context.Background(), fanoutInterval)
+	trimInterval := time.Hour
+	if s := os.Getenv("TIMELINE_TRIM_INTERVAL"); s != "" {
+		trimInterval, err = time.ParseDuration(s)
+		if err != nil || trimInterval <= 0 {
+			log.Fatalf("invalid TIMELINE_TRIM_INTERVAL: %q", s)
+		}
+	}
+	go apiCfg.runTimelineTrimmer(context.Background(), trimInterval)
+
 	serveMux := http.NewServeMux()
 	middleware := apiCfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir("."))))
 	serveMux.Handle("/app/", middleware)
+	serveMux.HandleFunc("GET /api/limits", apiCfg.getLimits)
 	serveMux.HandleFunc("GET /api/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
@@ -66,15 +302,65 @@ func main() {
 	})
 	serveMux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
 	serveMux.HandleFunc("POST /admin/reset", apiCfg.middlewareMetricsReset)
+	serveMux.HandleFunc("POST /admin/chirps/{chirpID}/restore", apiCfg.restoreChirp)
+	serveMux.HandleFunc("PUT /admin/users/{userID}/role", apiCfg.setUserRole)
+	serveMux.HandleFunc("GET /admin/reports", apiCfg.getReportCases)
+	serveMux.HandleFunc("GET /admin/reports/{caseID}", apiCfg.getReportCase)
+	serveMux.HandleFunc("PUT /admin/reports/{caseID}", apiCfg.updateReportCase)
+	serveMux.HandleFunc("POST /admin/reports/{caseID}/actions", apiCfg.createReportAction)
 	serveMux.HandleFunc("POST /api/users", apiCfg.createUser)
 	serveMux.HandleFunc("POST /api/chirps", apiCfg.createChirp)
 	serveMux.HandleFunc("GET /api/chirps", apiCfg.getChirps)
+	serveMux.HandleFunc("GET /api/chirps/search", apiCfg.searchChirps)
+	serveMux.HandleFunc("GET /api/chirps/scheduled", apiCfg.getScheduledChirps)
 	serveMux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.getChirpByID)
+	serveMux.HandleFunc("GET /api/mentions", apiCfg.getMentions)
+	serveMux.HandleFunc("GET /api/tags/trending", apiCfg.getTrendingTags)
+	serveMux.HandleFunc("GET /api/tags/{tag}/chirps", apiCfg.getTagChirps)
 	serveMux.HandleFunc("POST /api/login", apiCfg.login)
 	serveMux.HandleFunc("POST /api/refresh", apiCfg.refresh)
 	serveMux.HandleFunc("POST /api/revoke", apiCfg.revoke)
 	serveMux.HandleFunc("PUT /api/users", apiCfg.updateUser)
+	serveMux.HandleFunc("POST /api/users/me/export", apiCfg.createExport)
+	serveMux.HandleFunc("GET /api/users/me/exports/{exportID}", apiCfg.getExport)
+	serveMux.HandleFunc("POST /api/users/me/import", apiCfg.importArchive)
+	serveMux.HandleFunc("POST /api/users/me/import/twitter", apiCfg.importTwitterArchive)
+	serveMux.HandleFunc("GET /api/exports/{exportID}/download", apiCfg.downloadExport)
+	serveMux.HandleFunc("PUT /api/chirps/{chirpID}", apiCfg.updateChirp)
+	serveMux.HandleFunc("PUT /api/chirps/{chirpID}/schedule", apiCfg.rescheduleChirp)
+	serveMux.HandleFunc("DELETE /api/chirps/{chirpID}/schedule", apiCfg.cancelScheduledChirp)
+	serveMux.HandleFunc("GET /api/chirps/{chirpID}/history", apiCfg.getChirpHistory)
+	serveMux.HandleFunc("POST /api/chirps/{chirpID}/replies", apiCfg.createReply)
+	serveMux.HandleFunc("GET /api/chirps/{chirpID}/thread", apiCfg.getChirpThread)
+	serveMux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", apiCfg.rechirp)
+	serveMux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", apiCfg.undoRechirp)
+	serveMux.HandleFunc("POST /api/chirps/{chirpID}/quote", apiCfg.quoteChirp)
+	serveMux.HandleFunc("POST /api/chirps/{chirpID}/poll/votes", apiCfg.votePoll)
+	serveMux.HandleFunc("POST /api/chirps/{chirpID}/like", apiCfg.likeChirp)
+	serveMux.HandleFunc("DELETE /api/chirps/{chirpID}/like", apiCfg.unlikeChirp)
+	serveMux.HandleFunc("GET /api/users/{userID}", apiCfg.getUserProfile)
+	serveMux.HandleFunc("POST /api/users/{userID}/follow", apiCfg.followUser)
+	serveMux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.unfollowUser)
+	serveMux.HandleFunc("GET /api/users/{userID}/followers", apiCfg.getFollowers)
+	serveMux.HandleFunc("GET /api/users/{userID}/following", apiCfg.getFollowing)
+	serveMux.HandleFunc("GET /api/timeline", apiCfg.getTimeline)
+	serveMux.HandleFunc("GET /api/users/{userID}/likes", apiCfg.getUserLikes)
+	serveMux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", apiCfg.bookmarkChirp)
+	serveMux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", apiCfg.unbookmarkChirp)
+	serveMux.HandleFunc("GET /api/bookmarks", apiCfg.getBookmarks)
 	serveMux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.deleteChirp)
+	serveMux.HandleFunc("POST /api/chirps/{chirpID}/takedown", apiCfg.takedownChirp)
+	serveMux.HandleFunc("PUT /api/chirps/{chirpID}/content-warning", apiCfg.setContentWarning)
+	serveMux.HandleFunc("POST /api/reports", apiCfg.createReport)
+	serveMux.HandleFunc("POST /api/drafts", apiCfg.createDraft)
+	serveMux.HandleFunc("GET /api/drafts", apiCfg.getDrafts)
+	serveMux.HandleFunc("PUT /api/drafts/{draftID}", apiCfg.updateDraft)
+	serveMux.HandleFunc("DELETE /api/drafts/{draftID}", apiCfg.deleteDraft)
+	serveMux.HandleFunc("POST /api/drafts/{draftID}/publish", apiCfg.publishDraft)
+	serveMux.HandleFunc("POST /api/media", apiCfg.uploadMedia)
+	serveMux.HandleFunc("PUT /api/media/{mediaID}", apiCfg.updateMedia)
+	serveMux.HandleFunc("GET /media/{mediaID}", apiCfg.serveMedia)
+	serveMux.HandleFunc("GET /media/{mediaID}/thumbnail", apiCfg.serveMediaThumbnail)
 	serveMux.HandleFunc("POST /api/polka/webhooks", apiCfg.polkaWebhook)
 	if err := http.ListenAndServe(":8080", serveMux); err != nil {
 		fmt.Println(err)
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"GoServer/internal/auth"
+	"GoServer/internal/database"
+	"GoServer/internal/moderation"
+	"GoServer/internal/trending"
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// testConfig connects to the database in TEST_DB_URL, skipping the test when
+// it is not set. The database must be migrated; tests only ever delete the
+// rows they created.
+func testConfig(t *testing.T) *apiConfig {
+	t.Helper()
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &apiConfig{
+		DB:                         database.New(db),
+		DBConn:                     db,
+		Secret:                     "test-secret",
+		Trends:                     trending.NewStore(),
+		Moderation:                 moderation.Default(),
+		ChirpRetention:             time.Hour,
+		ChirpMaxLength:             140,
+		ChirpMaxLengthRed:          1000,
+		TimelineMaxLength:          800,
+		TimelineFanoutMaxFollowers: 10000,
+	}
+}
+
+// createTestUser creates a user, deleted again with its chirps when the test
+// ends, and returns it with an access token.
+func createTestUser(t *testing.T, cfg *apiConfig) (database.User, string) {
+	t.Helper()
+	user, err := cfg.DB.CreateUser(context.Background(), database.CreateUserParams{
+		Email:          "test-" + uuid.NewString() + "@example.com",
+		HashedPassword: "unused",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := cfg.DBConn.Exec(`DELETE FROM users WHERE id = $1`, user.ID); err != nil {
+			t.Error(err)
+		}
+	})
+	token, err := auth.MakeJWT(user.ID, cfg.Secret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user, token
+}
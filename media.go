@@ -0,0 +1,340 @@
+package main
+
+import (
+	"GoServer/internal/blobstore"
+	"GoServer/internal/database"
+	"GoServer/internal/media"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxChirpMedia = 4
+	maxAltText    = 1000
+)
+
+// errMediaNotAttachable is returned when a chirp references media that does
+// not exist, belongs to someone else or is already attached to a chirp.
+var errMediaNotAttachable = errors.New("Media not found or already attached")
+
+type ChirpMedia struct {
+	ID           uuid.UUID `json:"id"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	AltText      string    `json:"alt_text"`
+}
+
+func chirpMediaFromDB(file database.MediaFile) ChirpMedia {
+	return ChirpMedia{
+		ID:           file.ID,
+		URL:          "/media/" + file.ID.String(),
+		ThumbnailURL: "/media/" + file.ID.String() + "/thumbnail",
+		ContentType:  file.ContentType,
+		Width:        file.Width,
+		Height:       file.Height,
+		AltText:      file.AltText,
+	}
+}
+
+func validateAltText(altText string) error {
+	if utf8.RuneCountInString(altText) > maxAltText {
+		return fmt.Errorf("Alt text must be at most %d characters", maxAltText)
+	}
+	return nil
+}
+
+func (cfg *apiConfig) uploadMedia(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// leave some room for the multipart framing and the alt text field
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.MediaMaxBytes+64<<10)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "File is too large", nil)
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Missing file", err)
+		return
+	}
+	defer file.Close()
+
+	altText := r.FormValue("alt_text")
+	if err := validateAltText(altText); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, cfg.MediaMaxBytes+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error reading file", err)
+		return
+	}
+	if int64(len(data)) > cfg.MediaMaxBytes {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "File is too large", nil)
+		return
+	}
+
+	mediaFile, err := cfg.storeMedia(r.Context(), cfg.DB, userID, data, altText)
+	if err != nil {
+		if errors.Is(err, media.ErrUnsupportedType) {
+			respondWithError(w, http.StatusUnsupportedMediaType, "Only JPEG, PNG and GIF images are supported", nil)
+			return
+		}
+		var invalid *invalidMediaError
+		if errors.As(err, &invalid) {
+			respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error saving media", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, chirpMediaFromDB(mediaFile))
+}
+
+// invalidMediaError is returned by storeMedia for uploads that are not a
+// usable image.
+type invalidMediaError struct {
+	err error
+}
+
+func (e *invalidMediaError) Error() string { return e.err.Error() }
+func (e *invalidMediaError) Unwrap() error { return e.err }
+
+// storeMedia processes an uploaded image, stores it and its thumbnail and
+// records them for userID. If q is bound to a transaction that is rolled
+// back, the caller has to delete the blobs named in the returned file.
+func (cfg *apiConfig) storeMedia(ctx context.Context, q *database.Queries, userID uuid.UUID, data []byte, altText string) (database.MediaFile, error) {
+	processed, err := media.Process(data)
+	if err != nil {
+		return database.MediaFile{}, &invalidMediaError{err}
+	}
+
+	id := uuid.New()
+	storageKey := id.String()
+	thumbnailKey := id.String() + ".thumb"
+	if err := cfg.Blobs.Put(ctx, storageKey, bytes.NewReader(processed.Data)); err != nil {
+		return database.MediaFile{}, err
+	}
+	if err := cfg.Blobs.Put(ctx, thumbnailKey, bytes.NewReader(processed.Thumbnail)); err != nil {
+		cfg.deleteBlobs(storageKey)
+		return database.MediaFile{}, err
+	}
+
+	mediaFile, err := q.CreateMediaFile(ctx, database.CreateMediaFileParams{
+		ID:                   id,
+		UserID:               userID,
+		ContentType:          processed.ContentType,
+		SizeBytes:            int64(len(processed.Data)),
+		Width:                int32(processed.Width),
+		Height:               int32(processed.Height),
+		StorageKey:           storageKey,
+		ThumbnailKey:         thumbnailKey,
+		ThumbnailContentType: processed.ThumbnailContentType,
+		AltText:              altText,
+	})
+	if err != nil {
+		cfg.deleteBlobs(storageKey, thumbnailKey)
+		return database.MediaFile{}, err
+	}
+	return mediaFile, nil
+}
+
+// deleteBlobs cleans up after a failed upload. It uses its own context so
+// that the cleanup still runs when the request was cancelled.
+func (cfg *apiConfig) deleteBlobs(keys ...string) {
+	for _, key := range keys {
+		if err := cfg.Blobs.Delete(context.Background(), key); err != nil {
+			log.Printf("Error deleting blob %s: %s", key, err)
+		}
+	}
+}
+
+func (cfg *apiConfig) updateMedia(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	mediaID, err := uuid.Parse(r.PathValue("mediaID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid media ID format", err)
+		return
+	}
+
+	params := struct {
+		AltText string `json:"alt_text"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error unmarshalling media", err)
+		return
+	}
+	if err := validateAltText(params.AltText); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	mediaFile, err := cfg.DB.GetMediaFileByID(r.Context(), mediaID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Media not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving media", err)
+		return
+	}
+	if mediaFile.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "You can only edit your own media", nil)
+		return
+	}
+
+	mediaFile, err = cfg.DB.UpdateMediaAltText(r.Context(), database.UpdateMediaAltTextParams{AltText: params.AltText, ID: mediaID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating media", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, chirpMediaFromDB(mediaFile))
+}
+
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMediaFile(w, r, false)
+}
+
+func (cfg *apiConfig) serveMediaThumbnail(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMediaFile(w, r, true)
+}
+
+// serveMediaFile writes a stored image to whoever may see the chirp it is
+// attached to. Stored files never change once uploaded, so images of public
+// chirps may be cached forever by anyone; the others only privately.
+func (cfg *apiConfig) serveMediaFile(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	viewerID, ok := cfg.optionalUserID(w, r)
+	if !ok {
+		return
+	}
+	mediaID, err := uuid.Parse(r.PathValue("mediaID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid media ID format", err)
+		return
+	}
+
+	mediaFile, err := cfg.DB.GetMediaFileByID(r.Context(), mediaID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Media not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving media", err)
+		return
+	}
+
+	visible, public, err := cfg.mediaVisibility(r.Context(), mediaFile, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving media", err)
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "Media not found", nil)
+		return
+	}
+
+	key, contentType, etag := mediaFile.StorageKey, mediaFile.ContentType, `"`+mediaFile.ID.String()+`"`
+	if thumbnail {
+		key, contentType, etag = mediaFile.ThumbnailKey, mediaFile.ThumbnailContentType, `"`+mediaFile.ID.String()+`-thumb"`
+	}
+
+	if public {
+		// the file never changes, but whether it may be shown does: caches
+		// keep it for a minute, then revalidate against the ETag, so
+		// deletes, takedowns and visibility changes reach them quickly
+		w.Header().Set("Cache-Control", "public, max-age=60, must-revalidate")
+	} else {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	blob, err := cfg.Blobs.Get(r.Context(), key)
+	if err != nil {
+		w.Header().Del("Cache-Control")
+		if errors.Is(err, blobstore.ErrNotFound) {
+			respondWithError(w, http.StatusNotFound, "Media not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error reading media", err)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if !thumbnail {
+		w.Header().Set("Content-Length", strconv.FormatInt(mediaFile.SizeBytes, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, blob); err != nil {
+		log.Printf("Error writing media %s: %s", mediaFile.ID, err)
+	}
+}
+
+// mediaVisibility reports whether viewerID may see mediaFile, and whether
+// anyone may. Images not attached to a chirp are only shown to the uploader,
+// and images of deleted chirps to nobody.
+func (cfg *apiConfig) mediaVisibility(ctx context.Context, mediaFile database.MediaFile, viewerID uuid.UUID) (visible, public bool, err error) {
+	if !mediaFile.ChirpID.Valid {
+		return viewerID != uuid.Nil && mediaFile.UserID == viewerID, false, nil
+	}
+	chirp, err := cfg.DB.GetVisibleChirpByID(ctx, database.GetVisibleChirpByIDParams{ID: mediaFile.ChirpID.UUID, ViewerID: viewerID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	if chirp.DeletedAt.Valid {
+		return false, false, nil
+	}
+	public = !chirp.PublishAt.Valid && (chirp.Visibility == visibilityPublic || chirp.Visibility == visibilityUnlisted)
+	return true, public, nil
+}
+
+// attachMedia attaches the caller's uploaded media to a new chirp. q should
+// be bound to the transaction that created the chirp.
+func attachMedia(ctx context.Context, q *database.Queries, userID, chirpID uuid.UUID, mediaIDs []uuid.UUID) error {
+	if len(mediaIDs) == 0 {
+		return nil
+	}
+	unique := map[uuid.UUID]bool{}
+	for _, id := range mediaIDs {
+		unique[id] = true
+	}
+	attached, err := q.AttachMedia(ctx, database.AttachMediaParams{ChirpID: chirpID, Ids: mediaIDs, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if attached != int64(len(unique)) {
+		return errMediaNotAttachable
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/pagination"
+	"database/sql"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) getMentions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if page.Cursor != nil && page.Cursor.Backward {
+		respondWithError(w, http.StatusBadRequest, "Mentions can only be paged forward", nil)
+		return
+	}
+
+	params := database.ListMentionsOfUserParams{UserID: userID, RowLimit: int32(page.Limit + 1)}
+	if page.Cursor != nil {
+		params.CursorCreatedAt = sql.NullTime{Time: page.Cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.Cursor.ID, Valid: true}
+	}
+	chirps, err := cfg.DB.ListMentionsOfUser(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get mentions", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(chirps) > page.Limit {
+		chirps = chirps[:page.Limit]
+		nextCursor = chirpCursor(chirps[len(chirps)-1]).Encode()
+	}
+
+	chirpsResponse, err := cfg.buildChirps(r.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get mentions", err)
+		return
+	}
+
+	pagination.SetLinkHeader(w, r, nextCursor, "")
+	respondWithJSON(w, http.StatusOK, pagination.Page[Chirp]{
+		Items:      chirpsResponse,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/pagination"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// maxCaseNotes is the longest moderator notes on a case, in characters.
+const maxCaseNotes = 5000
+
+// Where a case of the moderation queue stands.
+const (
+	caseOpen      = "open"
+	caseActioned  = "actioned"
+	caseDismissed = "dismissed"
+)
+
+// What a moderator did about a case. Every action but a dismissal leaves
+// the case actioned.
+const (
+	actionTakedown   = "takedown"
+	actionWarning    = "warning"
+	actionSuspension = "suspension"
+	actionDismissal  = "dismissal"
+)
+
+type ReportAction struct {
+	ID             uuid.UUID  `json:"id"`
+	CaseID         uuid.UUID  `json:"case_id"`
+	ModeratorID    *uuid.UUID `json:"moderator_id"`
+	Action         string     `json:"action"`
+	Note           string     `json:"note"`
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type ReportCase struct {
+	ID            uuid.UUID  `json:"id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	TargetUserID  uuid.UUID  `json:"target_user_id"`
+	TargetChirpID *uuid.UUID `json:"target_chirp_id"`
+	Status        string     `json:"status"`
+	AssigneeID    *uuid.UUID `json:"assignee_id"`
+	Notes         string     `json:"notes"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+	ReportCount   int64      `json:"report_count"`
+	// Reports and Actions are only set when a single case is requested.
+	Reports []Report       `json:"reports,omitempty"`
+	Actions []ReportAction `json:"actions,omitempty"`
+}
+
+func reportCaseFromDB(reportCase database.ReportCase, reportCount int64) ReportCase {
+	response := ReportCase{
+		ID:           reportCase.ID,
+		CreatedAt:    reportCase.CreatedAt,
+		UpdatedAt:    reportCase.UpdatedAt,
+		TargetUserID: reportCase.TargetUserID,
+		Status:       reportCase.Status,
+		Notes:        reportCase.Notes,
+		ReportCount:  reportCount,
+	}
+	if reportCase.TargetChirpID.Valid {
+		response.TargetChirpID = &reportCase.TargetChirpID.UUID
+	}
+	if reportCase.AssigneeID.Valid {
+		response.AssigneeID = &reportCase.AssigneeID.UUID
+	}
+	if reportCase.ResolvedAt.Valid {
+		response.ResolvedAt = &reportCase.ResolvedAt.Time
+	}
+	return response
+}
+
+func reportActionFromDB(action database.ReportAction) ReportAction {
+	response := ReportAction{
+		ID:        action.ID,
+		CaseID:    action.CaseID,
+		Action:    action.Action,
+		Note:      action.Note,
+		CreatedAt: action.CreatedAt,
+	}
+	if action.ModeratorID.Valid {
+		response.ModeratorID = &action.ModeratorID.UUID
+	}
+	if action.SuspendedUntil.Valid {
+		response.SuspendedUntil = &action.SuspendedUntil.Time
+	}
+	return response
+}
+
+// getReportCases lists the cases of the moderation queue with the given
+// status, open by default, oldest first. assignee_id narrows it to the cases
+// of one moderator, or the caller's own with "me".
+func (cfg *apiConfig) getReportCases(w http.ResponseWriter, r *http.Request) {
+	moderatorID, ok := cfg.requireRole(w, r, roleModerator)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	page, err := pagination.ParseParams(query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if page.Cursor != nil && page.Cursor.Backward {
+		respondWithError(w, http.StatusBadRequest, "The moderation queue can only be paged forward", nil)
+		return
+	}
+
+	params := database.ListReportCasesParams{Status: caseOpen, RowLimit: int32(page.Limit + 1)}
+	switch status := query.Get("status"); status {
+	case "":
+	case caseOpen, caseActioned, caseDismissed:
+		params.Status = status
+	default:
+		respondWithError(w, http.StatusBadRequest, "status must be open, actioned or dismissed", nil)
+		return
+	}
+	switch assignee := query.Get("assignee_id"); assignee {
+	case "":
+	case "me":
+		params.AssigneeID = uuid.NullUUID{UUID: moderatorID, Valid: true}
+	default:
+		assigneeID, err := uuid.Parse(assignee)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid assignee_id", nil)
+			return
+		}
+		params.AssigneeID = uuid.NullUUID{UUID: assigneeID, Valid: true}
+	}
+	if page.Cursor != nil {
+		params.CursorCreatedAt = sql.NullTime{Time: page.Cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.Cursor.ID, Valid: true}
+	}
+
+	cases, err := cfg.DB.ListReportCases(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving reports", err)
+		return
+	}
+	nextCursor := ""
+	if len(cases) > page.Limit {
+		cases = cases[:page.Limit]
+		last := cases[len(cases)-1]
+		nextCursor = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+
+	ids := make([]uuid.UUID, len(cases))
+	for i, reportCase := range cases {
+		ids[i] = reportCase.ID
+	}
+	counts := map[uuid.UUID]int64{}
+	if len(ids) > 0 {
+		rows, err := cfg.DB.GetReportCounts(r.Context(), ids)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error retrieving reports", err)
+			return
+		}
+		for _, row := range rows {
+			counts[row.CaseID] = row.ReportCount
+		}
+	}
+
+	response := make([]ReportCase, len(cases))
+	for i, reportCase := range cases {
+		response[i] = reportCaseFromDB(reportCase, counts[reportCase.ID])
+	}
+	pagination.SetLinkHeader(w, r, nextCursor, "")
+	respondWithJSON(w, http.StatusOK, pagination.Page[ReportCase]{
+		Items:      response,
+		NextCursor: nextCursor,
+	})
+}
+
+// getReportCase returns a case with all of its reports and the actions
+// taken on it.
+func (cfg *apiConfig) getReportCase(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.requireRole(w, r, roleModerator); !ok {
+		return
+	}
+	caseID, err := uuid.Parse(r.PathValue("caseID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid case ID format", err)
+		return
+	}
+
+	reportCase, err := cfg.DB.GetReportCase(r.Context(), caseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Case not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving case", err)
+		return
+	}
+	response, err := cfg.buildReportCase(r.Context(), reportCase)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving case", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) buildReportCase(ctx context.Context, reportCase database.ReportCase) (ReportCase, error) {
+	reports, err := cfg.DB.ListCaseReports(ctx, reportCase.ID)
+	if err != nil {
+		return ReportCase{}, err
+	}
+	actions, err := cfg.DB.ListReportActions(ctx, reportCase.ID)
+	if err != nil {
+		return ReportCase{}, err
+	}
+
+	response := reportCaseFromDB(reportCase, int64(len(reports)))
+	response.Reports = make([]Report, len(reports))
+	for i, report := range reports {
+		response.Reports[i] = reportFromDB(report)
+	}
+	response.Actions = make([]ReportAction, len(actions))
+	for i, action := range actions {
+		response.Actions[i] = reportActionFromDB(action)
+	}
+	return response, nil
+}
+
+// updateReportCase sets who is handling a case and their notes. Both fields
+// are replaced, so a null assignee_id unassigns the case.
+func (cfg *apiConfig) updateReportCase(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.requireRole(w, r, roleModerator); !ok {
+		return
+	}
+	caseID, err := uuid.Parse(r.PathValue("caseID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid case ID format", err)
+		return
+	}
+
+	params := struct {
+		AssigneeID *uuid.UUID `json:"assignee_id"`
+		Notes      string     `json:"notes"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error unmarshalling case", err)
+		return
+	}
+	if utf8.RuneCountInString(params.Notes) > maxCaseNotes {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("notes can be at most %d characters", maxCaseNotes), nil)
+		return
+	}
+	assigneeID := uuid.NullUUID{}
+	if params.AssigneeID != nil {
+		assignee, err := cfg.DB.GetUserByID(r.Context(), *params.AssigneeID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusInternalServerError, "Error retrieving user", err)
+			return
+		}
+		if err != nil || roleRanks[assignee.Role] < roleRanks[roleModerator] {
+			respondWithError(w, http.StatusBadRequest, "Cases can only be assigned to moderators", nil)
+			return
+		}
+		assigneeID = uuid.NullUUID{UUID: assignee.ID, Valid: true}
+	}
+
+	reportCase, err := cfg.DB.UpdateReportCase(r.Context(), database.UpdateReportCaseParams{
+		AssigneeID: assigneeID,
+		Notes:      params.Notes,
+		ID:         caseID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Case not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error updating case", err)
+		return
+	}
+	response, err := cfg.buildReportCase(r.Context(), reportCase)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving case", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// caseActionError is an action that does not apply to the case it was
+// taken on.
+type caseActionError struct {
+	status int
+	msg    string
+}
+
+func (e *caseActionError) Error() string { return e.msg }
+
+// createReportAction takes and records an action on a case:
+//   - takedown removes the reported chirp, showing note as the reason
+//   - warning only records that the author was warned
+//   - suspension bars the reported account from the API until
+//     suspended_until and revokes its refresh tokens; only accounts with a
+//     lower role than the moderator's can be suspended
+//   - dismissal closes an open case without consequences
+//
+// Several actions can be taken on one case, but dismissed cases are closed.
+func (cfg *apiConfig) createReportAction(w http.ResponseWriter, r *http.Request) {
+	moderatorID, ok := cfg.requireRole(w, r, roleModerator)
+	if !ok {
+		return
+	}
+	caseID, err := uuid.Parse(r.PathValue("caseID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid case ID format", err)
+		return
+	}
+
+	params := struct {
+		Action         string     `json:"action"`
+		Note           string     `json:"note"`
+		SuspendedUntil *time.Time `json:"suspended_until"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error unmarshalling action", err)
+		return
+	}
+	params.Note = strings.TrimSpace(params.Note)
+	switch params.Action {
+	case actionTakedown:
+		if params.Note == "" || len(params.Note) > maxDeletionReason {
+			respondWithError(w, http.StatusBadRequest, "A takedown needs a note of at most 500 bytes, shown as its reason", nil)
+			return
+		}
+	case actionSuspension:
+		if params.SuspendedUntil == nil || !params.SuspendedUntil.After(time.Now()) {
+			respondWithError(w, http.StatusBadRequest, "A suspension needs a suspended_until in the future", nil)
+			return
+		}
+	case actionWarning, actionDismissal:
+	default:
+		respondWithError(w, http.StatusBadRequest, "action must be takedown, warning, suspension or dismissal", nil)
+		return
+	}
+	if utf8.RuneCountInString(params.Note) > maxCaseNotes {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("note can be at most %d characters", maxCaseNotes), nil)
+		return
+	}
+	suspendedUntil := sql.NullTime{}
+	if params.Action == actionSuspension {
+		suspendedUntil = sql.NullTime{Time: params.SuspendedUntil.UTC(), Valid: true}
+	}
+
+	var action database.ReportAction
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		reportCase, err := q.GetReportCaseForUpdate(r.Context(), caseID)
+		if err != nil {
+			return err
+		}
+		if reportCase.Status == caseDismissed {
+			return &caseActionError{http.StatusConflict, "Case is dismissed"}
+		}
+
+		status := caseActioned
+		switch params.Action {
+		case actionTakedown:
+			if !reportCase.TargetChirpID.Valid {
+				return &caseActionError{http.StatusBadRequest, "Only reported chirps can be taken down"}
+			}
+			err := softDeleteChirp(r.Context(), q, reportCase.TargetChirpID.UUID, moderatorID, deletionByModerator, params.Note)
+			if errors.Is(err, sql.ErrNoRows) {
+				return &caseActionError{http.StatusConflict, "Chirp is already deleted"}
+			}
+			if err != nil {
+				return err
+			}
+		case actionSuspension:
+			moderator, err := q.GetUserByID(r.Context(), moderatorID)
+			if err != nil {
+				return err
+			}
+			target, err := q.GetUserByID(r.Context(), reportCase.TargetUserID)
+			if err != nil {
+				return err
+			}
+			if roleRanks[target.Role] >= roleRanks[moderator.Role] {
+				return &caseActionError{http.StatusForbidden, "Only users with a lower role can be suspended"}
+			}
+			if _, err := q.SuspendUser(r.Context(), database.SuspendUserParams{
+				SuspendedUntil: suspendedUntil,
+				ID:             reportCase.TargetUserID,
+			}); err != nil {
+				return err
+			}
+			if err := q.RevokeUserRefreshTokens(r.Context(), reportCase.TargetUserID); err != nil {
+				return err
+			}
+		case actionDismissal:
+			if reportCase.Status != caseOpen {
+				return &caseActionError{http.StatusConflict, "Only open cases can be dismissed"}
+			}
+			status = caseDismissed
+		}
+
+		action, err = q.CreateReportAction(r.Context(), database.CreateReportActionParams{
+			CaseID:         reportCase.ID,
+			ModeratorID:    uuid.NullUUID{UUID: moderatorID, Valid: true},
+			Action:         params.Action,
+			Note:           params.Note,
+			SuspendedUntil: suspendedUntil,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.ResolveReportCase(r.Context(), database.ResolveReportCaseParams{Status: status, ID: reportCase.ID})
+		return err
+	})
+	if err != nil {
+		var actionErr *caseActionError
+		if errors.As(err, &actionErr) {
+			respondWithError(w, actionErr.status, actionErr.msg, nil)
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Case not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error taking action", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, reportActionFromDB(action))
+}
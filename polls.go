@@ -0,0 +1,255 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/textlen"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	minPollOptions   = 2
+	maxPollOptions   = 4
+	maxPollOptionLen = 25
+	maxPollDuration  = 7 * 24 * time.Hour
+)
+
+// PollParams is the poll part of a createChirp request.
+type PollParams struct {
+	Options        []string  `json:"options"`
+	ClosesAt       time.Time `json:"closes_at"`
+	MultipleChoice bool      `json:"multiple_choice"`
+	// HideResults keeps the tallies from people who have not voted until the
+	// poll closes.
+	HideResults bool `json:"hide_results"`
+}
+
+type Poll struct {
+	ClosesAt       time.Time `json:"closes_at"`
+	Closed         bool      `json:"closed"`
+	MultipleChoice bool      `json:"multiple_choice"`
+	HideResults    bool      `json:"hide_results"`
+	// ResultsVisible reports whether the vote counts are included.
+	ResultsVisible bool         `json:"results_visible"`
+	VoterCount     *int64       `json:"voter_count,omitempty"`
+	Options        []PollOption `json:"options"`
+	VotedByMe      bool         `json:"voted_by_me"`
+}
+
+type PollOption struct {
+	ID        uuid.UUID `json:"id"`
+	Text      string    `json:"text"`
+	Votes     *int64    `json:"votes,omitempty"`
+	VotedByMe bool      `json:"voted_by_me"`
+}
+
+// validatePoll checks a new poll. publishAt is the publish time of a
+// scheduled chirp, which the poll must outlive.
+func validatePoll(poll *PollParams, publishAt sql.NullTime) error {
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return fmt.Errorf("A poll needs between %d and %d options", minPollOptions, maxPollOptions)
+	}
+	seen := map[string]bool{}
+	for i, option := range poll.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || textlen.Graphemes(option) > maxPollOptionLen {
+			return fmt.Errorf("Poll options must be between 1 and %d characters", maxPollOptionLen)
+		}
+		if seen[strings.ToLower(option)] {
+			return errors.New("Poll options must be different")
+		}
+		seen[strings.ToLower(option)] = true
+		poll.Options[i] = option
+	}
+
+	if err := validatePollWindow(poll.ClosesAt, publishAt); err != nil {
+		return err
+	}
+	poll.ClosesAt = poll.ClosesAt.UTC()
+	return nil
+}
+
+// validatePollWindow checks that a poll closing at closesAt is open for some
+// time, but no longer than maxPollDuration, once its chirp is published.
+func validatePollWindow(closesAt time.Time, publishAt sql.NullTime) error {
+	opensAt := time.Now()
+	if publishAt.Valid {
+		opensAt = publishAt.Time
+	}
+	if !closesAt.After(opensAt) || closesAt.Sub(opensAt) > maxPollDuration {
+		return errors.New("A poll must close within 7 days of being published")
+	}
+	return nil
+}
+
+// insertPoll attaches a validated poll to a new chirp. q should be bound to
+// the transaction that created the chirp.
+func insertPoll(ctx context.Context, q *database.Queries, chirpID uuid.UUID, poll *PollParams) error {
+	if poll == nil {
+		return nil
+	}
+	err := q.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:        chirpID,
+		ClosesAt:       poll.ClosesAt,
+		MultipleChoice: poll.MultipleChoice,
+		HideResults:    poll.HideResults,
+	})
+	if err != nil {
+		return err
+	}
+	for i, option := range poll.Options {
+		err := q.CreatePollOption(ctx, database.CreatePollOptionParams{ChirpID: chirpID, Position: int32(i), Text: option})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getChirpPolls loads the polls of the given chirps as viewerID sees them.
+func (cfg *apiConfig) getChirpPolls(ctx context.Context, viewerID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]*Poll, error) {
+	rows, err := cfg.DB.GetPolls(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	polls := make(map[uuid.UUID]*Poll, len(rows))
+	if len(rows) == 0 {
+		return polls, nil
+	}
+	pollIDs := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		pollIDs[i] = row.ChirpID
+		polls[row.ChirpID] = &Poll{
+			ClosesAt:       row.ClosesAt,
+			Closed:         !time.Now().Before(row.ClosesAt),
+			MultipleChoice: row.MultipleChoice,
+			HideResults:    row.HideResults,
+			VoterCount:     &row.VoterCount,
+			Options:        []PollOption{},
+		}
+	}
+
+	options, err := cfg.DB.GetPollOptions(ctx, database.GetPollOptionsParams{ViewerID: viewerID, ChirpIds: pollIDs})
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		poll := polls[option.ChirpID]
+		poll.Options = append(poll.Options, PollOption{
+			ID:        option.ID,
+			Text:      option.Text,
+			Votes:     &option.Votes,
+			VotedByMe: option.VotedByViewer,
+		})
+		poll.VotedByMe = poll.VotedByMe || option.VotedByViewer
+	}
+
+	for _, poll := range polls {
+		poll.ResultsVisible = !poll.HideResults || poll.Closed || poll.VotedByMe
+		if poll.ResultsVisible {
+			continue
+		}
+		poll.VoterCount = nil
+		for i := range poll.Options {
+			poll.Options[i].Votes = nil
+		}
+	}
+	return polls, nil
+}
+
+func (cfg *apiConfig) votePoll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return
+	}
+
+	params := struct {
+		OptionIDs []uuid.UUID `json:"option_ids"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error unmarshalling votes", err)
+		return
+	}
+
+	chirp, err := cfg.DB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{ID: chirpID, ViewerID: userID})
+	if err == nil && (chirp.DeletedAt.Valid || chirp.PublishAt.Valid) {
+		err = sql.ErrNoRows
+	}
+	var poll database.Poll
+	if err == nil {
+		poll, err = cfg.DB.GetPoll(r.Context(), chirpID)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Poll not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving poll", err)
+		return
+	}
+	if !time.Now().Before(poll.ClosesAt) {
+		respondWithError(w, http.StatusConflict, "Poll is closed", nil)
+		return
+	}
+
+	unique := map[uuid.UUID]bool{}
+	for _, id := range params.OptionIDs {
+		unique[id] = true
+	}
+	if len(unique) == 0 || len(unique) != len(params.OptionIDs) {
+		respondWithError(w, http.StatusBadRequest, "option_ids must list distinct options", nil)
+		return
+	}
+	if !poll.MultipleChoice && len(unique) > 1 {
+		respondWithError(w, http.StatusBadRequest, "This poll allows a single choice", nil)
+		return
+	}
+
+	// poll_voters allows a single ballot per user, and the foreign keys of
+	// poll_votes reject options from other polls
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		if err := q.CreatePollVoter(r.Context(), database.CreatePollVoterParams{ChirpID: chirpID, UserID: userID}); err != nil {
+			return err
+		}
+		for id := range unique {
+			err := q.CreatePollVote(r.Context(), database.CreatePollVoteParams{ChirpID: chirpID, OptionID: id, UserID: userID})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "You have already voted", nil)
+			return
+		}
+		if isForeignKeyViolation(err) {
+			respondWithError(w, http.StatusBadRequest, "Unknown poll option", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error casting vote", err)
+		return
+	}
+
+	polls, err := cfg.getChirpPolls(r.Context(), userID, []uuid.UUID{chirpID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving poll", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, polls[chirpID])
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// maxReportDetails is the longest free-text explanation a report can carry,
+// in characters.
+const maxReportDetails = 1000
+
+// Why a chirp or account was reported.
+var reportCategories = map[string]bool{
+	"spam":           true,
+	"harassment":     true,
+	"hate":           true,
+	"violence":       true,
+	"sexual":         true,
+	"self_harm":      true,
+	"misinformation": true,
+	"impersonation":  true,
+	"other":          true,
+}
+
+type Report struct {
+	ID         uuid.UUID `json:"id"`
+	CaseID     uuid.UUID `json:"case_id"`
+	ReporterID uuid.UUID `json:"reporter_id"`
+	Category   string    `json:"category"`
+	Details    string    `json:"details"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func reportFromDB(report database.Report) Report {
+	return Report{
+		ID:         report.ID,
+		CaseID:     report.CaseID,
+		ReporterID: report.ReporterID,
+		Category:   report.Category,
+		Details:    report.Details,
+		CreatedAt:  report.CreatedAt,
+	}
+}
+
+// suspended reports whether user is barred from logging in at now.
+func suspended(user database.User, now time.Time) bool {
+	return user.SuspendedUntil.Valid && user.SuspendedUntil.Time.After(now)
+}
+
+// createReport files a report against a chirp, given as chirp_id, or an
+// account, given as user_id. Reports about the same target are grouped into
+// one open case of the moderation queue.
+func (cfg *apiConfig) createReport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	params := struct {
+		ChirpID  *uuid.UUID `json:"chirp_id"`
+		UserID   *uuid.UUID `json:"user_id"`
+		Category string     `json:"category"`
+		Details  string     `json:"details"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error unmarshalling report", err)
+		return
+	}
+	if (params.ChirpID == nil) == (params.UserID == nil) {
+		respondWithError(w, http.StatusBadRequest, "Exactly one of chirp_id or user_id is required", nil)
+		return
+	}
+	if !reportCategories[params.Category] {
+		respondWithError(w, http.StatusBadRequest, "Invalid report category", nil)
+		return
+	}
+	params.Details = strings.TrimSpace(params.Details)
+	if utf8.RuneCountInString(params.Details) > maxReportDetails {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("details can be at most %d characters", maxReportDetails), nil)
+		return
+	}
+
+	caseParams := database.OpenReportCaseParams{}
+	if params.ChirpID != nil {
+		// rechirps are reported as the chirp they repost
+		chirp, err := cfg.getOriginalChirp(r.Context(), userID, *params.ChirpID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+			return
+		}
+		caseParams.TargetUserID = chirp.UserID
+		caseParams.TargetChirpID = uuid.NullUUID{UUID: chirp.ID, Valid: true}
+	} else {
+		user, err := cfg.DB.GetUserByID(r.Context(), *params.UserID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusNotFound, "User not found", nil)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Error retrieving user", err)
+			return
+		}
+		caseParams.TargetUserID = user.ID
+	}
+	if caseParams.TargetUserID == userID {
+		respondWithError(w, http.StatusBadRequest, "You cannot report yourself", nil)
+		return
+	}
+
+	var report database.Report
+	err := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		reportCase, err := q.OpenReportCase(r.Context(), caseParams)
+		if err != nil {
+			return err
+		}
+		report, err = q.CreateReport(r.Context(), database.CreateReportParams{
+			CaseID:     reportCase.ID,
+			ReporterID: userID,
+			Category:   params.Category,
+			Details:    params.Details,
+		})
+		return err
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "You have already reported this", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error creating report", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, reportFromDB(report))
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSuspendedUserCannotUpdateAccountOrDeleteChirps(t *testing.T) {
+	cfg := testConfig(t)
+	ctx := context.Background()
+	user, token := createTestUser(t, cfg)
+	chirp, err := cfg.DB.CreateChirp(ctx, database.CreateChirpParams{Body: "before the suspension", UserID: user.ID, Visibility: visibilityPublic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.DB.SuspendUser(ctx, database.SuspendUserParams{
+		SuspendedUntil: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+		ID:             user.ID,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("PUT", "/api/users", strings.NewReader(`{"email":"suspended@example.com","password":"new password"}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	cfg.updateUser(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("PUT /api/users: expected 403, got %d: %s", rec.Code, rec.Body)
+	}
+
+	req = httptest.NewRequest("DELETE", "/api/chirps/"+chirp.ID.String(), nil)
+	req.SetPathValue("chirpID", chirp.ID.String())
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	cfg.deleteChirp(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("DELETE /api/chirps/{chirpID}: expected 403, got %d: %s", rec.Code, rec.Body)
+	}
+
+	stored, err := cfg.DB.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Email != user.Email {
+		t.Fatalf("expected the email to stay %q, got %q", user.Email, stored.Email)
+	}
+	chirp, err = cfg.DB.GetChirpByID(ctx, chirp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chirp.DeletedAt.Valid {
+		t.Fatal("expected the chirp not to be deleted")
+	}
+}
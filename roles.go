@@ -0,0 +1,78 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// User roles, from least to most privileged. Every role can do what the
+// ones before it can.
+const (
+	roleUser      = "user"
+	roleModerator = "moderator"
+	roleAdmin     = "admin"
+)
+
+var roleRanks = map[string]int{
+	roleUser:      0,
+	roleModerator: 1,
+	roleAdmin:     2,
+}
+
+// requireRole is requireUserID for endpoints restricted to users holding at
+// least the given role.
+func (cfg *apiConfig) requireRole(w http.ResponseWriter, r *http.Request, role string) (uuid.UUID, bool) {
+	user, ok := cfg.requireUser(w, r)
+	if !ok {
+		return uuid.Nil, false
+	}
+	if roleRanks[user.Role] < roleRanks[role] {
+		respondWithError(w, http.StatusForbidden, "Insufficient permissions", nil)
+		return uuid.Nil, false
+	}
+	return user.ID, true
+}
+
+func (cfg *apiConfig) setUserRole(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.requireRole(w, r, roleAdmin); !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID format", err)
+		return
+	}
+
+	params := struct {
+		Role string `json:"role"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error unmarshalling role", err)
+		return
+	}
+	if _, ok := roleRanks[params.Role]; !ok {
+		respondWithError(w, http.StatusBadRequest, "role must be user, moderator or admin", nil)
+		return
+	}
+
+	user, err := cfg.DB.SetUserRole(r.Context(), database.SetUserRoleParams{Role: params.Role, ID: userID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "User not found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error updating user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, struct {
+		ID   uuid.UUID `json:"id"`
+		Role string    `json:"role"`
+	}{ID: user.ID, Role: user.Role})
+}
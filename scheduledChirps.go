@@ -0,0 +1,215 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// publishBatchSize is how many due chirps one publisher transaction claims.
+const publishBatchSize = 100
+
+// parsePublishAt validates the optional publish time of a new chirp. Times
+// are stored in UTC.
+func parsePublishAt(publishAt *time.Time) (sql.NullTime, error) {
+	if publishAt == nil {
+		return sql.NullTime{}, nil
+	}
+	if !publishAt.After(time.Now()) {
+		return sql.NullTime{}, errors.New("publish_at must be in the future")
+	}
+	return sql.NullTime{Time: publishAt.UTC(), Valid: true}, nil
+}
+
+// runPublisher publishes due scheduled chirps each interval until ctx is
+// done. Every server may run one: rows are claimed with SKIP LOCKED.
+func (cfg *apiConfig) runPublisher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			published, err := cfg.publishDueChirps(ctx)
+			if err != nil {
+				log.Printf("Error publishing scheduled chirps: %s", err)
+				break
+			}
+			if published < publishBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDueChirps publishes one batch of due chirps, indexing their hashtags
+// and mentions and queueing their timeline fan-out, all of which are left
+// out while a chirp is scheduled. Publish times are stored in UTC, so they
+// are compared with the time in UTC rather than the database session's.
+func (cfg *apiConfig) publishDueChirps(ctx context.Context) (int, error) {
+	var published int
+	err := cfg.withTx(ctx, func(q *database.Queries) error {
+		chirps, err := q.PublishDueChirps(ctx, database.PublishDueChirpsParams{
+			Now:   time.Now().UTC(),
+			Limit: publishBatchSize,
+		})
+		if err != nil {
+			return err
+		}
+		for _, chirp := range chirps {
+			if err := cfg.indexChirpEntities(ctx, q, chirp); err != nil {
+				return err
+			}
+			if err := q.EnqueueFanout(ctx, chirp.ID); err != nil {
+				return err
+			}
+		}
+		published = len(chirps)
+		return nil
+	})
+	return published, err
+}
+
+func (cfg *apiConfig) getScheduledChirps(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirps, err := cfg.DB.ListScheduledChirps(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirps", err)
+		return
+	}
+	response, err := cfg.buildChirps(r.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirps", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// getScheduledChirp loads a chirp for rescheduling or cancelling, writing
+// the error response itself when the caller may not change it.
+func (cfg *apiConfig) getScheduledChirp(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (database.Chirp, bool) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID format", err)
+		return database.Chirp{}, false
+	}
+
+	chirp, err := cfg.DB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{ID: chirpID, ViewerID: userID})
+	if err == nil && chirp.DeletedAt.Valid {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+			return database.Chirp{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return database.Chirp{}, false
+	}
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "Cannot schedule another user's chirp", nil)
+		return database.Chirp{}, false
+	}
+	if !chirp.PublishAt.Valid {
+		respondWithError(w, http.StatusConflict, "Chirp is already published", nil)
+		return database.Chirp{}, false
+	}
+	return chirp, true
+}
+
+func (cfg *apiConfig) rescheduleChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	params := struct {
+		PublishAt *time.Time `json:"publish_at"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error unmarshalling Chirp", err)
+		return
+	}
+	if params.PublishAt == nil {
+		respondWithError(w, http.StatusBadRequest, "publish_at is required", nil)
+		return
+	}
+	publishAt, err := parsePublishAt(params.PublishAt)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	chirp, ok := cfg.getScheduledChirp(w, r, userID)
+	if !ok {
+		return
+	}
+	poll, err := cfg.DB.GetPoll(r.Context(), chirp.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving poll", err)
+		return
+	}
+	if err == nil {
+		// the poll keeps its closing time, so it has to fit the new date
+		if err := validatePollWindow(poll.ClosesAt, publishAt); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+	}
+
+	chirp, err = cfg.DB.RescheduleChirp(r.Context(), database.RescheduleChirpParams{PublishAt: publishAt, ID: chirp.ID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// the publisher got to it first
+			respondWithError(w, http.StatusConflict, "Chirp is already published", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error rescheduling chirp", err)
+		return
+	}
+
+	response, err := cfg.buildChirp(r.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) cancelScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	chirp, ok := cfg.getScheduledChirp(w, r, userID)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.DB.CancelScheduledChirp(r.Context(), chirp.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error cancelling chirp", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusConflict, "Chirp is already published", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestPublisherSkipsDeletedScheduledChirps(t *testing.T) {
+	cfg := testConfig(t)
+	ctx := context.Background()
+	user, _ := createTestUser(t, cfg)
+	chirp, err := cfg.DB.CreateChirp(ctx, database.CreateChirpParams{
+		Body:       "scheduled #tombstone",
+		UserID:     user.ID,
+		PublishAt:  sql.NullTime{Time: time.Now().UTC().Add(-time.Minute), Valid: true},
+		Visibility: visibilityPublic,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.removeChirp(ctx, chirp, user.ID, deletionByAuthor, authorDeletionReason); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		published, err := cfg.publishDueChirps(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if published < publishBatchSize {
+			break
+		}
+	}
+
+	chirp, err = cfg.DB.GetChirpByID(ctx, chirp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !chirp.PublishAt.Valid {
+		t.Fatal("expected the deleted chirp to stay unpublished")
+	}
+	var tags, jobs int
+	if err := cfg.DBConn.QueryRowContext(ctx, `SELECT COUNT(*) FROM chirp_tags WHERE chirp_id = $1`, chirp.ID).Scan(&tags); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.DBConn.QueryRowContext(ctx, `SELECT COUNT(*) FROM timeline_fanout_jobs WHERE chirp_id = $1`, chirp.ID).Scan(&jobs); err != nil {
+		t.Fatal(err)
+	}
+	if tags != 0 || jobs != 0 {
+		t.Fatalf("expected no tags and no fan-out job, got %d tags and %d jobs", tags, jobs)
+	}
+}
@@ -0,0 +1,8 @@
+-- name: GetImportedChirpID :one
+SELECT chirp_id FROM chirp_imports
+WHERE user_id = $1 AND source_id = $2;
+
+-- name: RecordChirpImport :execrows
+INSERT INTO chirp_imports (user_id, source_id, chirp_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, source_id) DO NOTHING;
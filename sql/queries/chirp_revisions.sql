@@ -0,0 +1,23 @@
+-- name: CreateChirpRevision :one
+INSERT INTO chirp_revisions (id, chirp_id, body, created_at, replaced_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+RETURNING *;
+
+-- name: GetChirpRevisions :many
+SELECT * FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY replaced_at DESC;
+
+-- name: PurgeDeletedChirpRevisions :execrows
+DELETE FROM chirp_revisions
+WHERE chirp_id IN (
+    SELECT id FROM chirps
+    WHERE deleted_at <= NOW() - make_interval(secs => sqlc.arg('retention_seconds')::float8)
+      AND NOT EXISTS (SELECT 1 FROM report_cases WHERE report_cases.target_chirp_id = chirps.id)
+);
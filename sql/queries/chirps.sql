@@ -1,27 +1,326 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, quote_of_id, publish_at, visibility, content_warning, sensitive)
 VALUES (
     gen_random_uuid(),
+    COALESCE($6::timestamp, NOW()),
     NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6::timestamp,
+    $7,
+    $8,
+    $9
+)
+RETURNING *;
+
+-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, rechirp_of_id)
+VALUES (
+    gen_random_uuid(),
     NOW(),
+    NOW(),
+    '',
     $1,
     $2
 )
+ON CONFLICT (user_id, rechirp_of_id) WHERE rechirp_of_id IS NOT NULL AND deleted_at IS NULL DO NOTHING
 RETURNING *;
 
--- name: GetChirps :many
+-- name: GetUserRechirp :one
 SELECT * FROM chirps
-ORDER BY created_at ASC;
+WHERE user_id = $1
+  AND rechirp_of_id = $2
+  AND deleted_at IS NULL;
+
+-- name: GetRechirpStats :many
+SELECT
+    original.id AS chirp_id,
+    COUNT(*) FILTER (WHERE chirps.rechirp_of_id = original.id) AS rechirp_count,
+    COUNT(*) FILTER (WHERE chirps.quote_of_id = original.id) AS quote_count
+FROM unnest(sqlc.arg('chirp_ids')::uuid[]) AS original(id)
+JOIN chirps ON chirps.rechirp_of_id = original.id OR chirps.quote_of_id = original.id
+WHERE chirps.deleted_at IS NULL
+GROUP BY original.id;
 
 -- name: GetChirpByID :one
 SELECT * FROM chirps
 WHERE id = $1;
 
+-- name: GetVisibleChirpByID :one
+SELECT * FROM chirps
+WHERE id = sqlc.arg('id')::uuid
+  AND chirp_visible(id, user_id, visibility, publish_at, sqlc.arg('viewer_id')::uuid, false);
+
 -- name: DeleteChirpByID :exec
 DELETE FROM chirps
 WHERE id = $1;
 
--- name: GetChirpsByUserID :many
+-- name: ListChirpsAfter :many
+SELECT * FROM chirps
+WHERE (deleted_at IS NULL OR deletion_kind = 'moderator')
+  AND chirp_visible(id, user_id, visibility, publish_at, sqlc.arg('viewer_id')::uuid, true)
+  AND (sqlc.narg('author_ids')::uuid[] IS NULL OR user_id = ANY(sqlc.narg('author_ids')::uuid[]))
+  AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since')::timestamp)
+  AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until')::timestamp)
+  AND (sqlc.narg('has_media')::boolean IS NULL
+       OR EXISTS (SELECT 1 FROM media_files WHERE media_files.chirp_id = chirps.id) = sqlc.narg('has_media')::boolean)
+  AND (sqlc.narg('is_reply')::boolean IS NULL OR (parent_id IS NOT NULL) = sqlc.narg('is_reply')::boolean)
+  AND (sqlc.narg('tag')::text IS NULL OR EXISTS (
+       SELECT 1 FROM chirp_tags
+       JOIN tags ON tags.id = chirp_tags.tag_id
+       WHERE chirp_tags.chirp_id = chirps.id AND tags.name = sqlc.narg('tag')::text
+  ))
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+       OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('row_limit');
+
+-- name: ListChirpsBefore :many
+SELECT * FROM chirps
+WHERE (deleted_at IS NULL OR deletion_kind = 'moderator')
+  AND chirp_visible(id, user_id, visibility, publish_at, sqlc.arg('viewer_id')::uuid, true)
+  AND (sqlc.narg('author_ids')::uuid[] IS NULL OR user_id = ANY(sqlc.narg('author_ids')::uuid[]))
+  AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since')::timestamp)
+  AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until')::timestamp)
+  AND (sqlc.narg('has_media')::boolean IS NULL
+       OR EXISTS (SELECT 1 FROM media_files WHERE media_files.chirp_id = chirps.id) = sqlc.narg('has_media')::boolean)
+  AND (sqlc.narg('is_reply')::boolean IS NULL OR (parent_id IS NOT NULL) = sqlc.narg('is_reply')::boolean)
+  AND (sqlc.narg('tag')::text IS NULL OR EXISTS (
+       SELECT 1 FROM chirp_tags
+       JOIN tags ON tags.id = chirp_tags.tag_id
+       WHERE chirp_tags.chirp_id = chirps.id AND tags.name = sqlc.narg('tag')::text
+  ))
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+       OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: ListChirpsByEngagement :many
+SELECT chirps.id, engagement.score
+FROM chirps
+CROSS JOIN LATERAL (
+    SELECT (SELECT COUNT(*) FROM likes WHERE likes.chirp_id = chirps.id)
+         + (SELECT COUNT(*) FROM chirps AS other
+            WHERE other.deleted_at IS NULL
+              AND (other.parent_id = chirps.id OR other.rechirp_of_id = chirps.id OR other.quote_of_id = chirps.id)) AS score
+) AS engagement
+WHERE chirps.deleted_at IS NULL
+  AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, chirps.publish_at, sqlc.arg('viewer_id')::uuid, true)
+  AND (sqlc.narg('author_ids')::uuid[] IS NULL OR chirps.user_id = ANY(sqlc.narg('author_ids')::uuid[]))
+  AND chirps.created_at >= sqlc.arg('since')::timestamp
+  AND (sqlc.narg('until')::timestamp IS NULL OR chirps.created_at < sqlc.narg('until')::timestamp)
+  AND (sqlc.narg('has_media')::boolean IS NULL
+       OR EXISTS (SELECT 1 FROM media_files WHERE media_files.chirp_id = chirps.id) = sqlc.narg('has_media')::boolean)
+  AND (sqlc.narg('is_reply')::boolean IS NULL OR (chirps.parent_id IS NOT NULL) = sqlc.narg('is_reply')::boolean)
+  AND (sqlc.narg('tag')::text IS NULL OR EXISTS (
+       SELECT 1 FROM chirp_tags
+       JOIN tags ON tags.id = chirp_tags.tag_id
+       WHERE chirp_tags.chirp_id = chirps.id AND tags.name = sqlc.narg('tag')::text
+  ))
+  AND (sqlc.narg('cursor_score')::bigint IS NULL
+       OR engagement.score < sqlc.narg('cursor_score')::bigint
+       OR (engagement.score = sqlc.narg('cursor_score')::bigint AND chirps.id > sqlc.narg('cursor_id')::uuid))
+ORDER BY engagement.score DESC, chirps.id ASC
+LIMIT sqlc.arg('row_limit');
+
+-- name: SearchChirps :many
+SELECT id,
+    ts_rank(search_vector, to_tsquery('simple', sqlc.arg('query')::text))::float8 AS rank,
+    ts_headline(
+        'simple',
+        replace(replace(replace(body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+        to_tsquery('simple', sqlc.arg('query')::text),
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2'
+    ) AS snippet
+FROM chirps
+WHERE search_vector @@ to_tsquery('simple', sqlc.arg('query')::text)
+  AND deleted_at IS NULL
+  AND publish_at IS NULL
+  AND chirp_visible(id, user_id, visibility, publish_at, sqlc.arg('viewer_id')::uuid, true)
+  AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+  AND (sqlc.narg('cursor_rank')::float8 IS NULL
+       OR ts_rank(search_vector, to_tsquery('simple', sqlc.arg('query')::text))::float8 < sqlc.narg('cursor_rank')::float8
+       OR (ts_rank(search_vector, to_tsquery('simple', sqlc.arg('query')::text))::float8 = sqlc.narg('cursor_rank')::float8
+           AND id > sqlc.narg('cursor_id')::uuid))
+ORDER BY rank DESC, id ASC
+LIMIT sqlc.arg('row_limit');
+
+-- name: GetChirpByIDForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateChirpContent :one
+UPDATE chirps
+SET
+  body = $1,
+  content_warning = $2,
+  sensitive = $3,
+  edit_count = edit_count + 1,
+  updated_at = NOW()
+WHERE id = $4
+RETURNING *;
+
+-- name: SetChirpContentWarning :one
+UPDATE chirps
+SET
+  content_warning = $1,
+  sensitive = $2,
+  moderator_warning = $1 IS NOT NULL OR $2
+WHERE id = $3
+  AND deleted_at IS NULL
+  AND rechirp_of_id IS NULL
+RETURNING *;
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+  AND chirp_visible(id, user_id, visibility, publish_at, sqlc.arg('viewer_id')::uuid, false);
+
+-- name: GetReplyCounts :many
+SELECT parent_id::uuid AS chirp_id, COUNT(*) AS reply_count
+FROM chirps
+WHERE parent_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+  AND deleted_at IS NULL
+  AND publish_at IS NULL
+  AND chirp_visible(id, user_id, visibility, publish_at, sqlc.arg('viewer_id')::uuid, false)
+GROUP BY parent_id;
+
+-- name: HasReplies :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps
+    WHERE parent_id = $1
+);
+
+-- name: SoftDeleteChirp :execrows
+UPDATE chirps
+SET
+  deleted_at = NOW(),
+  updated_at = NOW(),
+  deleted_by = $1,
+  deletion_kind = $2,
+  deletion_reason = $3
+WHERE id = $4
+  AND deleted_at IS NULL;
+
+-- name: RestoreChirp :one
+UPDATE chirps
+SET
+  deleted_at = NULL,
+  updated_at = NOW(),
+  deleted_by = NULL,
+  deletion_kind = NULL,
+  deletion_reason = NULL
+WHERE id = sqlc.arg('id')::uuid
+  AND deleted_at > NOW() - make_interval(secs => sqlc.arg('retention_seconds')::float8)
+RETURNING *;
+
+-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE id IN (
+    SELECT c.id FROM chirps c
+    WHERE c.deleted_at <= NOW() - make_interval(secs => sqlc.arg('retention_seconds')::float8)
+      AND NOT EXISTS (SELECT 1 FROM chirps r WHERE r.parent_id = c.id)
+      AND NOT EXISTS (SELECT 1 FROM report_cases rc WHERE rc.target_chirp_id = c.id)
+    LIMIT sqlc.arg('row_limit')
+    FOR UPDATE SKIP LOCKED
+);
+
+-- name: ScrubDeletedChirps :execrows
+UPDATE chirps
+SET body = ''
+WHERE deleted_at <= NOW() - make_interval(secs => sqlc.arg('retention_seconds')::float8)
+  AND body <> ''
+  AND NOT EXISTS (SELECT 1 FROM report_cases WHERE report_cases.target_chirp_id = chirps.id);
+
+-- name: GetChirpAncestorIDs :many
+WITH RECURSIVE ancestors AS (
+    SELECT parent_id, 1 AS depth
+    FROM chirps
+    WHERE id = sqlc.arg('chirp_id')::uuid
+    UNION ALL
+    SELECT c.parent_id, a.depth + 1
+    FROM chirps c
+    JOIN ancestors a ON c.id = a.parent_id
+)
+SELECT parent_id::uuid AS id
+FROM ancestors
+WHERE parent_id IS NOT NULL
+ORDER BY depth DESC;
+
+-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT id, 1 AS depth, to_char(created_at, 'YYYYMMDDHH24MISSUS') || id::text AS path
+    FROM chirps
+    WHERE parent_id = sqlc.arg('chirp_id')::uuid
+    UNION ALL
+    SELECT c.id, d.depth + 1, d.path || '/' || to_char(c.created_at, 'YYYYMMDDHH24MISSUS') || c.id::text
+    FROM chirps c
+    JOIN descendants d ON c.parent_id = d.id
+    WHERE d.depth < sqlc.arg('max_depth')::int
+)
+SELECT id, depth, path
+FROM descendants
+WHERE sqlc.narg('cursor_path')::text IS NULL OR path > sqlc.narg('cursor_path')::text
+ORDER BY path
+LIMIT sqlc.arg('row_limit');
+
+-- name: ListScheduledChirps :many
 SELECT * FROM chirps
 WHERE user_id = $1
-ORDER BY created_at ASC;
\ No newline at end of file
+  AND publish_at IS NOT NULL
+  AND deleted_at IS NULL
+ORDER BY publish_at ASC, id ASC;
+
+-- name: RescheduleChirp :one
+UPDATE chirps
+SET publish_at = $1, created_at = $1, updated_at = NOW()
+WHERE id = $2
+  AND publish_at IS NOT NULL
+RETURNING *;
+
+-- name: CancelScheduledChirp :execrows
+DELETE FROM chirps
+WHERE id = $1
+  AND publish_at IS NOT NULL;
+
+-- name: PublishDueChirps :many
+UPDATE chirps
+SET publish_at = NULL
+WHERE id IN (
+    SELECT id FROM chirps
+    WHERE publish_at <= $1
+      AND deleted_at IS NULL
+    ORDER BY publish_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ImportChirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, quote_of_id, publish_at, visibility, content_warning, sensitive)
+SELECT
+    gen_random_uuid(),
+    sqlc.arg('created_at')::timestamp,
+    sqlc.arg('updated_at')::timestamp,
+    sqlc.arg('body')::text,
+    sqlc.arg('user_id')::uuid,
+    parent.id,
+    COALESCE(parent.root_id, parent.id),
+    quoted.id,
+    sqlc.narg('publish_at')::timestamp,
+    sqlc.arg('visibility')::text,
+    sqlc.narg('content_warning')::text,
+    sqlc.arg('sensitive')::boolean
+FROM (SELECT 1) AS one
+LEFT JOIN chirps AS parent
+    ON parent.id = sqlc.narg('parent_id')::uuid AND parent.deleted_at IS NULL
+    AND parent.publish_at IS NULL
+    AND chirp_visible(parent.id, parent.user_id, parent.visibility, parent.publish_at, sqlc.arg('user_id')::uuid, false)
+LEFT JOIN chirps AS quoted
+    ON quoted.id = sqlc.narg('quote_of_id')::uuid AND quoted.deleted_at IS NULL
+    AND quoted.visibility IN ('public', 'unlisted') AND quoted.publish_at IS NULL
+RETURNING *;
@@ -0,0 +1,35 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (id, created_at, updated_at, user_id, body, content_warning, sensitive)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: ListDrafts :many
+SELECT * FROM drafts
+WHERE user_id = $1
+ORDER BY updated_at DESC, id DESC;
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET body = $1, content_warning = $2, sensitive = $3, updated_at = NOW()
+WHERE id = $4
+  AND user_id = $5
+RETURNING *;
+
+-- name: GetDraftForUpdate :one
+SELECT * FROM drafts
+WHERE id = $1
+  AND user_id = $2
+FOR UPDATE;
+
+-- name: DeleteDraft :execrows
+DELETE FROM drafts
+WHERE id = $1
+  AND user_id = $2;
@@ -0,0 +1,63 @@
+-- name: CreateExportJob :one
+INSERT INTO export_jobs (id, user_id, status, created_at, updated_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    'pending',
+    NOW(),
+    NOW()
+)
+RETURNING *;
+
+-- name: GetExportJob :one
+SELECT * FROM export_jobs
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetFinishedExportJob :one
+SELECT * FROM export_jobs
+WHERE id = $1 AND status = 'done';
+
+-- name: ClaimExportJob :one
+UPDATE export_jobs
+SET status = 'running', updated_at = NOW()
+WHERE id = (
+    SELECT id FROM export_jobs
+    WHERE status = 'pending'
+       OR (status = 'running' AND updated_at < NOW() - make_interval(secs => sqlc.arg('stale_seconds')::float8))
+    ORDER BY created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteExportJob :exec
+UPDATE export_jobs
+SET status = 'done', storage_key = $1, size_bytes = $2, completed_at = NOW(), updated_at = NOW()
+WHERE id = $3;
+
+-- name: FailExportJob :exec
+UPDATE export_jobs
+SET status = 'failed', error = $1, completed_at = NOW(), updated_at = NOW()
+WHERE id = $2;
+
+-- name: DeleteExpiredExportJobs :many
+DELETE FROM export_jobs
+WHERE completed_at <= NOW() - make_interval(secs => sqlc.arg('retention_seconds')::float8)
+RETURNING storage_key;
+
+-- name: ListChirpsForExport :many
+SELECT * FROM chirps
+WHERE user_id = $1 AND deleted_at IS NULL
+ORDER BY created_at, id;
+
+-- name: ListLikesForExport :many
+SELECT chirp_id, created_at FROM likes
+WHERE user_id = $1
+ORDER BY created_at, chirp_id;
+
+-- name: ListFollowersForExport :many
+SELECT follows.follower_id, users.handle, follows.created_at
+FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = $1
+ORDER BY follows.created_at, follows.follower_id;
@@ -0,0 +1,51 @@
+-- name: FollowUser :execrows
+WITH followed AS (
+    INSERT INTO follows (follower_id, followee_id, created_at)
+    VALUES ($1, $2, NOW())
+    ON CONFLICT (follower_id, followee_id) DO NOTHING
+    RETURNING followee_id
+)
+UPDATE users
+SET follower_count = follower_count + 1
+FROM followed
+WHERE users.id = followed.followee_id;
+
+-- name: UnfollowUser :execrows
+WITH unfollowed AS (
+    DELETE FROM follows
+    WHERE follower_id = $1 AND followee_id = $2
+    RETURNING followee_id
+)
+UPDATE users
+SET follower_count = follower_count - 1
+FROM unfollowed
+WHERE users.id = unfollowed.followee_id;
+
+-- name: GetFollowStats :one
+SELECT
+    (SELECT COUNT(*) FROM follows WHERE followee_id = sqlc.arg('user_id')::uuid) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follower_id = sqlc.arg('user_id')::uuid) AS following_count,
+    EXISTS (
+        SELECT 1 FROM follows
+        WHERE follower_id = sqlc.arg('viewer_id')::uuid AND followee_id = sqlc.arg('user_id')::uuid
+    ) AS followed_by_viewer;
+
+-- name: ListFollowers :many
+SELECT users.id, users.handle, follows.created_at
+FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = sqlc.arg('user_id')::uuid
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+       OR (follows.created_at, follows.follower_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY follows.created_at DESC, follows.follower_id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: ListFollowing :many
+SELECT users.id, users.handle, follows.created_at
+FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = sqlc.arg('user_id')::uuid
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+       OR (follows.created_at, follows.followee_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY follows.created_at DESC, follows.followee_id DESC
+LIMIT sqlc.arg('row_limit');
@@ -0,0 +1,38 @@
+-- name: CreateMediaFile :one
+INSERT INTO media_files (id, created_at, user_id, content_type, size_bytes, width, height, storage_key, thumbnail_key, thumbnail_content_type, alt_text)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10
+)
+RETURNING *;
+
+-- name: GetMediaFileByID :one
+SELECT * FROM media_files
+WHERE id = $1;
+
+-- name: UpdateMediaAltText :one
+UPDATE media_files
+SET alt_text = $1
+WHERE id = $2
+RETURNING *;
+
+-- name: AttachMedia :execrows
+UPDATE media_files
+SET chirp_id = sqlc.arg('chirp_id')::uuid
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+  AND user_id = sqlc.arg('user_id')::uuid
+  AND chirp_id IS NULL;
+
+-- name: GetMediaForChirps :many
+SELECT * FROM media_files
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY created_at, id;
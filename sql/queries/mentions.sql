@@ -0,0 +1,23 @@
+-- name: AddMention :exec
+INSERT INTO mentions (chirp_id, user_id, handle, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (chirp_id, user_id) DO NOTHING;
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM mentions
+WHERE chirp_id = $1;
+
+-- name: GetChirpMentions :many
+SELECT * FROM mentions
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: ListMentionsOfUser :many
+SELECT chirps.* FROM chirps
+JOIN mentions ON mentions.chirp_id = chirps.id
+WHERE mentions.user_id = sqlc.arg('user_id')::uuid
+  AND chirps.deleted_at IS NULL
+  AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, chirps.publish_at, sqlc.arg('user_id')::uuid, false)
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+       OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('row_limit');
@@ -0,0 +1,8 @@
+-- name: CreateModerationFlag :exec
+INSERT INTO moderation_flags (id, created_at, chirp_id, rule)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2
+);
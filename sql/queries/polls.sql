@@ -0,0 +1,40 @@
+-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, created_at, closes_at, multiple_choice, hide_results)
+VALUES ($1, NOW(), $2, $3, $4);
+
+-- name: CreatePollOption :exec
+INSERT INTO poll_options (id, chirp_id, position, text)
+VALUES (gen_random_uuid(), $1, $2, $3);
+
+-- name: GetPoll :one
+SELECT * FROM polls
+WHERE chirp_id = $1;
+
+-- name: GetPolls :many
+SELECT polls.*, (
+    SELECT COUNT(*) FROM poll_voters
+    WHERE poll_voters.chirp_id = polls.chirp_id
+) AS voter_count
+FROM polls
+WHERE polls.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: GetPollOptions :many
+SELECT
+    poll_options.id,
+    poll_options.chirp_id,
+    poll_options.text,
+    COUNT(poll_votes.user_id) AS votes,
+    COALESCE(BOOL_OR(poll_votes.user_id = sqlc.arg('viewer_id')::uuid), false)::bool AS voted_by_viewer
+FROM poll_options
+LEFT JOIN poll_votes ON poll_votes.option_id = poll_options.id
+WHERE poll_options.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY poll_options.id
+ORDER BY poll_options.chirp_id, poll_options.position;
+
+-- name: CreatePollVoter :exec
+INSERT INTO poll_voters (chirp_id, user_id, created_at)
+VALUES ($1, $2, NOW());
+
+-- name: CreatePollVote :exec
+INSERT INTO poll_votes (chirp_id, option_id, user_id)
+VALUES ($1, $2, $3);
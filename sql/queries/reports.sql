@@ -0,0 +1,89 @@
+-- name: OpenReportCase :one
+INSERT INTO report_cases (id, created_at, updated_at, target_user_id, target_chirp_id)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2
+)
+ON CONFLICT (target_user_id, (COALESCE(target_chirp_id, target_user_id))) WHERE status = 'open'
+DO UPDATE SET updated_at = NOW()
+RETURNING *;
+
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, case_id, reporter_id, category, details)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: ListReportCases :many
+SELECT * FROM report_cases
+WHERE status = sqlc.arg('status')::text
+  AND (sqlc.narg('assignee_id')::uuid IS NULL OR assignee_id = sqlc.narg('assignee_id')::uuid)
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+       OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg('row_limit');
+
+-- name: GetReportCounts :many
+SELECT case_id, COUNT(*) AS report_count
+FROM reports
+WHERE case_id = ANY(sqlc.arg('case_ids')::uuid[])
+GROUP BY case_id;
+
+-- name: GetReportCase :one
+SELECT * FROM report_cases
+WHERE id = $1;
+
+-- name: GetReportCaseForUpdate :one
+SELECT * FROM report_cases
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListCaseReports :many
+SELECT * FROM reports
+WHERE case_id = $1
+ORDER BY created_at, id;
+
+-- name: UpdateReportCase :one
+UPDATE report_cases
+SET
+  assignee_id = $1,
+  notes = $2,
+  updated_at = NOW()
+WHERE id = $3
+RETURNING *;
+
+-- name: ResolveReportCase :one
+UPDATE report_cases
+SET
+  status = $1,
+  resolved_at = COALESCE(resolved_at, NOW()),
+  updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: CreateReportAction :one
+INSERT INTO report_actions (id, created_at, case_id, moderator_id, action, note, suspended_until)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: ListReportActions :many
+SELECT * FROM report_actions
+WHERE case_id = $1
+ORDER BY created_at, id;
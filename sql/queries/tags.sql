@@ -0,0 +1,43 @@
+-- name: UpsertTag :one
+INSERT INTO tags (id, created_at, name)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1
+)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: AddChirpTag :exec
+INSERT INTO chirp_tags (chirp_id, tag_id, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (chirp_id, tag_id) DO NOTHING;
+
+-- name: DeleteChirpTags :exec
+DELETE FROM chirp_tags
+WHERE chirp_id = $1;
+
+-- name: ListChirpsByTag :many
+SELECT chirps.* FROM chirps
+JOIN chirp_tags ON chirp_tags.chirp_id = chirps.id
+JOIN tags ON tags.id = chirp_tags.tag_id
+WHERE tags.name = sqlc.arg('tag')::text
+  AND chirps.deleted_at IS NULL
+  AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, chirps.publish_at, sqlc.arg('viewer_id')::uuid, true)
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+       OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: GetTagUsageByAge :many
+SELECT
+    tags.name,
+    FLOOR(EXTRACT(EPOCH FROM (NOW() - chirp_tags.created_at)) / sqlc.arg('bucket_seconds')::float8)::bigint AS age_bucket,
+    COUNT(*) AS uses
+FROM chirp_tags
+JOIN tags ON tags.id = chirp_tags.tag_id
+JOIN chirps ON chirps.id = chirp_tags.chirp_id
+WHERE chirp_tags.created_at > NOW() - make_interval(secs => sqlc.arg('window_seconds')::float8)
+  AND chirps.deleted_at IS NULL
+  AND chirps.visibility = 'public'
+GROUP BY tags.name, age_bucket;
@@ -0,0 +1,161 @@
+-- name: EnqueueFanout :exec
+INSERT INTO timeline_fanout_jobs (chirp_id, created_at)
+VALUES ($1, NOW())
+ON CONFLICT (chirp_id) DO NOTHING;
+
+-- name: ClaimFanoutJobs :many
+DELETE FROM timeline_fanout_jobs
+WHERE chirp_id IN (
+    SELECT chirp_id FROM timeline_fanout_jobs
+    ORDER BY created_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING chirp_id;
+
+-- name: MarkChirpPullOnRead :exec
+WITH marked AS (
+    UPDATE chirps
+    SET pull_on_read = users.follower_count > sqlc.arg('max_followers')::int
+    FROM users
+    WHERE chirps.id = sqlc.arg('chirp_id')::uuid
+      AND users.id = chirps.user_id
+    RETURNING chirps.user_id, chirps.pull_on_read
+)
+UPDATE users
+SET has_pulled_chirps = true
+FROM marked
+WHERE users.id = marked.user_id
+  AND marked.pull_on_read
+  AND NOT users.has_pulled_chirps;
+
+-- name: FanOutChirp :execrows
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT readers.user_id, chirps.id, chirps.user_id, chirps.created_at
+FROM chirps
+CROSS JOIN LATERAL (
+    SELECT chirps.user_id
+    UNION
+    SELECT follows.follower_id FROM follows
+    WHERE follows.followee_id = chirps.user_id
+      AND NOT chirps.pull_on_read
+) AS readers (user_id)
+WHERE chirps.id = $1
+  AND chirps.deleted_at IS NULL
+  AND chirps.publish_at IS NULL
+  AND chirps.visibility <> 'unlisted'
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: BackfillTimeline :exec
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT sqlc.arg('user_id')::uuid, chirps.id, chirps.user_id, chirps.created_at
+FROM chirps
+WHERE chirps.user_id = sqlc.arg('author_id')::uuid
+  AND chirps.deleted_at IS NULL
+  AND chirps.publish_at IS NULL
+  AND chirps.visibility <> 'unlisted'
+  AND NOT chirps.pull_on_read
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('row_limit')
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: RemoveTimelineAuthor :exec
+DELETE FROM timeline_entries
+WHERE user_id = $1 AND author_id = $2;
+
+-- name: TrimTimeline :exec
+DELETE FROM timeline_entries
+WHERE user_id = sqlc.arg('user_id')::uuid
+  AND chirp_id IN (
+    SELECT chirp_id FROM timeline_entries
+    WHERE user_id = sqlc.arg('user_id')::uuid
+    ORDER BY created_at DESC, chirp_id DESC
+    OFFSET sqlc.arg('max_length')::int
+);
+
+-- name: TrimTimelines :execrows
+DELETE FROM timeline_entries
+USING (
+    SELECT user_id, chirp_id
+    FROM (
+        SELECT
+            user_id,
+            chirp_id,
+            ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at DESC, chirp_id DESC) AS position
+        FROM timeline_entries
+        WHERE user_id IN (
+            SELECT user_id FROM timeline_entries
+            GROUP BY user_id
+            HAVING COUNT(*) > sqlc.arg('max_length')::int
+        )
+    ) AS ranked
+    WHERE position > sqlc.arg('max_length')::int
+) AS overflow
+WHERE timeline_entries.user_id = overflow.user_id
+  AND timeline_entries.chirp_id = overflow.chirp_id;
+
+-- name: ListTimelineEntriesBefore :many
+SELECT chirps.id, chirps.created_at
+FROM timeline_entries
+JOIN chirps ON chirps.id = timeline_entries.chirp_id
+WHERE timeline_entries.user_id = sqlc.arg('viewer_id')::uuid
+  AND chirps.deleted_at IS NULL
+  AND chirps.publish_at IS NULL
+  AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, chirps.publish_at, sqlc.arg('viewer_id')::uuid, true)
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+       OR (timeline_entries.created_at, timeline_entries.chirp_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY timeline_entries.created_at DESC, timeline_entries.chirp_id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: ListTimelineEntriesAfter :many
+SELECT chirps.id, chirps.created_at
+FROM timeline_entries
+JOIN chirps ON chirps.id = timeline_entries.chirp_id
+WHERE timeline_entries.user_id = sqlc.arg('viewer_id')::uuid
+  AND chirps.deleted_at IS NULL
+  AND chirps.publish_at IS NULL
+  AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, chirps.publish_at, sqlc.arg('viewer_id')::uuid, true)
+  AND (timeline_entries.created_at, timeline_entries.chirp_id) > (sqlc.arg('cursor_created_at')::timestamp, sqlc.arg('cursor_id')::uuid)
+ORDER BY timeline_entries.created_at, timeline_entries.chirp_id
+LIMIT sqlc.arg('row_limit');
+
+-- name: ListFollowedChirpsBefore :many
+SELECT recent.id, recent.created_at
+FROM follows
+JOIN users ON users.id = follows.followee_id
+CROSS JOIN LATERAL (
+    SELECT chirps.id, chirps.created_at FROM chirps
+    WHERE chirps.user_id = follows.followee_id
+      AND chirps.pull_on_read
+      AND chirps.deleted_at IS NULL
+      AND chirps.publish_at IS NULL
+      AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, chirps.publish_at, sqlc.arg('viewer_id')::uuid, true)
+      AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+           OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+    ORDER BY chirps.created_at DESC, chirps.id DESC
+    LIMIT sqlc.arg('row_limit')
+) AS recent
+WHERE follows.follower_id = sqlc.arg('viewer_id')::uuid
+  AND users.has_pulled_chirps
+ORDER BY recent.created_at DESC, recent.id DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: ListFollowedChirpsAfter :many
+SELECT recent.id, recent.created_at
+FROM follows
+JOIN users ON users.id = follows.followee_id
+CROSS JOIN LATERAL (
+    SELECT chirps.id, chirps.created_at FROM chirps
+    WHERE chirps.user_id = follows.followee_id
+      AND chirps.pull_on_read
+      AND chirps.deleted_at IS NULL
+      AND chirps.publish_at IS NULL
+      AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, chirps.publish_at, sqlc.arg('viewer_id')::uuid, true)
+      AND (chirps.created_at, chirps.id) > (sqlc.arg('cursor_created_at')::timestamp, sqlc.arg('cursor_id')::uuid)
+    ORDER BY chirps.created_at, chirps.id
+    LIMIT sqlc.arg('row_limit')
+) AS recent
+WHERE follows.follower_id = sqlc.arg('viewer_id')::uuid
+  AND users.has_pulled_chirps
+ORDER BY recent.created_at, recent.id
+LIMIT sqlc.arg('row_limit');
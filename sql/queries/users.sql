@@ -35,4 +35,39 @@ SET
   is_chirpy_red = true,
   updated_at = NOW()
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+-- name: SetUserHandle :one
+UPDATE users
+SET
+  handle = $1,
+  updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: GetUsersByHandles :many
+SELECT * FROM users
+WHERE lower(handle) = ANY(sqlc.arg('handles')::text[]);
+
+-- name: SetUserRole :one
+UPDATE users
+SET
+  role = $1,
+  updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: SetUserExpandContentWarnings :one
+UPDATE users
+SET
+  expand_content_warnings = $1,
+  updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: SuspendUser :one
+UPDATE users
+SET
+  suspended_until = $1,
+  updated_at = NOW()
+WHERE id = $2
+RETURNING *;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE moderation_flags (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    rule TEXT NOT NULL
+);
+
+CREATE INDEX moderation_flags_chirp_id_idx ON moderation_flags (chirp_id);
+
+-- +goose Down
+DROP TABLE moderation_flags;
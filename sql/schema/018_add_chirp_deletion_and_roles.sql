@@ -0,0 +1,24 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN deleted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+ADD COLUMN deletion_reason TEXT;
+
+UPDATE chirps
+SET deleted_by = user_id, deletion_reason = 'deleted by author'
+WHERE deleted_at IS NOT NULL;
+
+CREATE INDEX chirps_deleted_at_idx ON chirps (deleted_at)
+WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE users
+ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN role;
+
+DROP INDEX chirps_deleted_at_idx;
+
+ALTER TABLE chirps
+DROP COLUMN deletion_reason,
+DROP COLUMN deleted_by;
@@ -0,0 +1,45 @@
+-- +goose Up
+CREATE TABLE follows (
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX follows_followee_id_idx ON follows (followee_id, follower_id);
+
+ALTER TABLE chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'unlisted', 'followers', 'mentioned'));
+
+-- chirp_visible decides whether viewer_id may read a chirp. listed is true
+-- for listings and feeds, which leave out unlisted chirps. Authors always
+-- see their own chirps, including scheduled ones.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_visible(chirp_id UUID, author_id UUID, visibility TEXT, publish_at TIMESTAMP, viewer_id UUID, listed BOOLEAN)
+RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT author_id = viewer_id
+        OR (publish_at IS NULL AND (
+            visibility = 'public'
+            OR (visibility = 'unlisted' AND NOT listed)
+            OR (visibility = 'followers' AND EXISTS (
+                SELECT 1 FROM follows
+                WHERE follows.followee_id = author_id AND follows.follower_id = viewer_id
+            ))
+            OR (visibility = 'mentioned' AND EXISTS (
+                SELECT 1 FROM mentions
+                WHERE mentions.chirp_id = chirp_visible.chirp_id AND mentions.user_id = viewer_id
+            ))
+        ))
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_visible;
+
+ALTER TABLE chirps
+DROP COLUMN visibility;
+
+DROP TABLE follows;
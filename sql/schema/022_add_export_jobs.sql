@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE export_jobs (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'running', 'done', 'failed')),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    storage_key TEXT,
+    size_bytes BIGINT,
+    error TEXT
+);
+
+-- one export at a time per user
+CREATE UNIQUE INDEX export_jobs_user_id_active_idx ON export_jobs (user_id)
+WHERE status IN ('pending', 'running');
+
+CREATE INDEX export_jobs_status_created_at_idx ON export_jobs (status, created_at);
+
+-- +goose Down
+DROP TABLE export_jobs;
@@ -0,0 +1,59 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN suspended_until TIMESTAMP;
+
+-- report_cases group the reports about one chirp, or about an account when
+-- target_chirp_id is NULL, into a single item of the moderation queue.
+CREATE TABLE report_cases (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    target_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'actioned', 'dismissed')),
+    assignee_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    resolved_at TIMESTAMP
+);
+
+-- a target has at most one open case, which new reports join
+CREATE UNIQUE INDEX report_cases_open_target_idx
+ON report_cases (target_user_id, (COALESCE(target_chirp_id, target_user_id)))
+WHERE status = 'open';
+
+CREATE INDEX report_cases_queue_idx ON report_cases (status, created_at, id);
+
+CREATE TABLE reports (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    case_id UUID NOT NULL REFERENCES report_cases(id) ON DELETE CASCADE,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category TEXT NOT NULL
+        CHECK (category IN ('spam', 'harassment', 'hate', 'violence', 'sexual', 'self_harm', 'misinformation', 'impersonation', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    UNIQUE (case_id, reporter_id)
+);
+
+CREATE TABLE report_actions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    case_id UUID NOT NULL REFERENCES report_cases(id) ON DELETE CASCADE,
+    moderator_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL
+        CHECK (action IN ('takedown', 'warning', 'suspension', 'dismissal')),
+    note TEXT NOT NULL DEFAULT '',
+    suspended_until TIMESTAMP
+);
+
+CREATE INDEX report_actions_case_id_idx ON report_actions (case_id, created_at);
+
+-- +goose Down
+DROP TABLE report_actions;
+
+DROP TABLE reports;
+
+DROP TABLE report_cases;
+
+ALTER TABLE users
+DROP COLUMN suspended_until;
@@ -0,0 +1,54 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN follower_count INTEGER NOT NULL DEFAULT 0;
+
+UPDATE users
+SET follower_count = counts.follower_count
+FROM (
+    SELECT followee_id, COUNT(*) AS follower_count
+    FROM follows
+    GROUP BY followee_id
+) AS counts
+WHERE users.id = counts.followee_id;
+
+CREATE TABLE timeline_entries (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX timeline_entries_user_id_created_at_idx ON timeline_entries (user_id, created_at, chirp_id);
+
+CREATE TABLE timeline_fanout_jobs (
+    chirp_id UUID PRIMARY KEY REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX timeline_fanout_jobs_created_at_idx ON timeline_fanout_jobs (created_at);
+
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT user_id, chirp_id, author_id, created_at
+FROM (
+    SELECT
+        readers.user_id,
+        chirps.id AS chirp_id,
+        chirps.user_id AS author_id,
+        chirps.created_at,
+        ROW_NUMBER() OVER (PARTITION BY readers.user_id ORDER BY chirps.created_at DESC, chirps.id DESC) AS position
+    FROM (
+        SELECT follower_id AS user_id, followee_id AS author_id FROM follows
+        UNION ALL
+        SELECT id, id FROM users
+    ) AS readers
+    JOIN chirps ON chirps.user_id = readers.author_id
+    WHERE chirps.deleted_at IS NULL
+      AND chirps.publish_at IS NULL
+      AND chirps.visibility <> 'unlisted'
+) AS ranked
+WHERE position <= 800;
+
+-- +goose Down
+DROP TABLE timeline_fanout_jobs;
+DROP TABLE timeline_entries;
+ALTER TABLE users DROP COLUMN follower_count;
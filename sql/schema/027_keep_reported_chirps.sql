@@ -0,0 +1,17 @@
+-- +goose Up
+-- reported chirps outlive the retention window so their cases keep the
+-- evidence; the purge skips them instead of cascading into the cases
+ALTER TABLE report_cases
+DROP CONSTRAINT report_cases_target_chirp_id_fkey,
+ADD CONSTRAINT report_cases_target_chirp_id_fkey
+    FOREIGN KEY (target_chirp_id) REFERENCES chirps(id);
+
+CREATE INDEX report_cases_target_chirp_id_idx ON report_cases (target_chirp_id);
+
+-- +goose Down
+DROP INDEX report_cases_target_chirp_id_idx;
+
+ALTER TABLE report_cases
+DROP CONSTRAINT report_cases_target_chirp_id_fkey,
+ADD CONSTRAINT report_cases_target_chirp_id_fkey
+    FOREIGN KEY (target_chirp_id) REFERENCES chirps(id) ON DELETE CASCADE;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- pull_on_read marks chirps the fan-out skipped because their author had
+-- too many followers; timelines merge them in on read from this index
+ALTER TABLE chirps
+ADD COLUMN pull_on_read BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX chirps_pull_on_read_idx ON chirps (user_id, created_at, id) WHERE pull_on_read;
+
+-- +goose Down
+DROP INDEX chirps_pull_on_read_idx;
+
+ALTER TABLE chirps
+DROP COLUMN pull_on_read;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- has_pulled_chirps lets timeline reads skip the pull_on_read index for the
+-- followed accounts that never had a chirp merged on read
+ALTER TABLE users
+ADD COLUMN has_pulled_chirps BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN has_pulled_chirps;
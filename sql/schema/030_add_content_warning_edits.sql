@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE drafts
+ADD COLUMN content_warning TEXT,
+ADD COLUMN sensitive BOOLEAN NOT NULL DEFAULT false;
+
+-- moderator_warning marks content warnings put on by moderators, which the
+-- author cannot change or lift
+ALTER TABLE chirps
+ADD COLUMN moderator_warning BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN moderator_warning;
+
+ALTER TABLE drafts
+DROP COLUMN sensitive,
+DROP COLUMN content_warning;
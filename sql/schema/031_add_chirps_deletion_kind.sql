@@ -0,0 +1,14 @@
+-- +goose Up
+-- deletion_kind records who removed a chirp, so a moderator taking down their
+-- own chirp still counts as a takedown
+ALTER TABLE chirps
+ADD COLUMN deletion_kind TEXT
+    CHECK (deletion_kind IN ('author', 'moderator'));
+
+UPDATE chirps
+SET deletion_kind = CASE WHEN deleted_by = user_id THEN 'author' ELSE 'moderator' END
+WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN deletion_kind;
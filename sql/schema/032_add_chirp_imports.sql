@@ -0,0 +1,13 @@
+-- +goose Up
+-- chirp_imports records which chirp each archived chirp was imported as.
+-- Imported chirps get new IDs, so an archive cannot claim the ID of a chirp
+-- that was purged while rechirps and quotes still point at it.
+CREATE TABLE chirp_imports (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source_id UUID NOT NULL,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, source_id)
+);
+
+-- +goose Down
+DROP TABLE chirp_imports;
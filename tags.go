@@ -0,0 +1,126 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/entities"
+	"GoServer/internal/pagination"
+	"GoServer/internal/trending"
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TrendingTags struct {
+	Window     string           `json:"window"`
+	ComputedAt time.Time        `json:"computed_at"`
+	Tags       []trending.Trend `json:"tags"`
+}
+
+// fetchTagUsage is the trending.FetchFunc backed by chirp_tags.
+func (cfg *apiConfig) fetchTagUsage(ctx context.Context, w trending.Window) ([]trending.Usage, error) {
+	rows, err := cfg.DB.GetTagUsageByAge(ctx, database.GetTagUsageByAgeParams{
+		BucketSeconds: w.Bucket().Seconds(),
+		WindowSeconds: w.Length.Seconds(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	usages := make([]trending.Usage, len(rows))
+	for i, row := range rows {
+		usages[i] = trending.Usage{Tag: row.Name, AgeBucket: row.AgeBucket, Uses: row.Uses}
+	}
+	return usages, nil
+}
+
+func (cfg *apiConfig) getTrendingTags(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	window := query.Get("window")
+	if window == "" {
+		window = trending.DefaultWindows[0].Name
+	}
+
+	limit := 10
+	if s := query.Get("limit"); s != "" {
+		var err error
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > trending.MaxTrends {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(trending.MaxTrends), nil)
+			return
+		}
+	}
+
+	trends, computedAt, ok := cfg.Trends.Get(window)
+	if !ok {
+		if !trending.IsWindow(window) {
+			respondWithError(w, http.StatusBadRequest, "Unknown trending window", nil)
+			return
+		}
+		respondWithError(w, http.StatusServiceUnavailable, "Trending tags are not computed yet", nil)
+		return
+	}
+	if len(trends) > limit {
+		trends = trends[:limit]
+	}
+
+	respondWithJSON(w, http.StatusOK, TrendingTags{
+		Window:     window,
+		ComputedAt: computedAt,
+		Tags:       trends,
+	})
+}
+
+func (cfg *apiConfig) getTagChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.optionalUserID(w, r)
+	if !ok {
+		return
+	}
+
+	tag := entities.NormalizeTag(r.PathValue("tag"))
+	if tag == "" {
+		respondWithError(w, http.StatusBadRequest, "Invalid tag", nil)
+		return
+	}
+
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if page.Cursor != nil && page.Cursor.Backward {
+		respondWithError(w, http.StatusBadRequest, "Tagged chirps can only be paged forward", nil)
+		return
+	}
+
+	params := database.ListChirpsByTagParams{Tag: tag, ViewerID: viewerID, RowLimit: int32(page.Limit + 1)}
+	if page.Cursor != nil {
+		params.CursorCreatedAt = sql.NullTime{Time: page.Cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: page.Cursor.ID, Valid: true}
+	}
+	chirps, err := cfg.DB.ListChirpsByTag(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get tagged chirps", err)
+		return
+	}
+
+	nextCursor := ""
+	if len(chirps) > page.Limit {
+		chirps = chirps[:page.Limit]
+		nextCursor = chirpCursor(chirps[len(chirps)-1]).Encode()
+	}
+
+	chirpsResponse, err := cfg.buildChirps(r.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get tagged chirps", err)
+		return
+	}
+
+	pagination.SetLinkHeader(w, r, nextCursor, "")
+	respondWithJSON(w, http.StatusOK, pagination.Page[Chirp]{
+		Items:      chirpsResponse,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"GoServer/internal/archive"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	parentID := uuid.New()
+	chirps := []archive.Chirp{
+		{ID: parentID, CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Body: "first", Visibility: "public"},
+		{
+			ID:         uuid.New(),
+			CreatedAt:  time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC),
+			Body:       "a reply, with \"quotes\"",
+			Visibility: "followers",
+			ParentID:   &parentID,
+			Media:      []archive.Media{{File: archive.MediaFile("img.png"), ContentType: "image/png", AltText: "a cat"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	w, err := archive.NewWriter(&buf, time.Now())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := w.WriteProfile(archive.Profile{ID: uuid.New(), Email: "a@example.com"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := w.WriteChirps(chirps); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := w.WriteMedia("img.png", strings.NewReader("image data")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	a, err := archive.Open(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(a.Chirps) != 2 {
+		t.Fatalf("expected 2 chirps, got %d", len(a.Chirps))
+	}
+	reply := a.Chirps[1]
+	if reply.Body != chirps[1].Body || !reply.CreatedAt.Equal(chirps[1].CreatedAt) || reply.ParentID == nil || *reply.ParentID != parentID {
+		t.Fatalf("expected %+v, got %+v", chirps[1], reply)
+	}
+
+	data, err := a.ReadMedia(reply.Media[0].File, 1<<10)
+	if err != nil || string(data) != "image data" {
+		t.Fatalf("expected the image data, got %q (%v)", data, err)
+	}
+	if _, err := a.ReadMedia(reply.Media[0].File, 4); !errors.Is(err, archive.ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+	if _, err := a.ReadMedia("chirps.json", 1<<20); !errors.Is(err, archive.ErrInvalid) {
+		t.Fatalf("expected ErrInvalid for a file outside media/, got %v", err)
+	}
+	if _, err := a.ReadMedia("media/../chirps.json", 1<<20); !errors.Is(err, archive.ErrInvalid) {
+		t.Fatalf("expected ErrInvalid for a path escaping media/, got %v", err)
+	}
+}
+
+func TestArchiveOpenRejectsOtherZips(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.Create("notes.txt")
+	f.Write([]byte("hello"))
+	zw.Close()
+
+	_, err := archive.Open(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if !errors.Is(err, archive.ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+
+	_, err = archive.Open(strings.NewReader("not a zip"), 9)
+	if !errors.Is(err, archive.ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+}
+
+func TestArchiveOpenRejectsTooManyChirps(t *testing.T) {
+	chirps := make([]archive.Chirp, archive.MaxChirps+1)
+	for i := range chirps {
+		chirps[i] = archive.Chirp{ID: uuid.New(), Body: "chirp", Visibility: "public"}
+	}
+	var buf bytes.Buffer
+	w, err := archive.NewWriter(&buf, time.Now())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := w.WriteChirps(chirps); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, err = archive.Open(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if !errors.Is(err, archive.ErrTooMany) {
+		t.Fatalf("expected ErrTooMany, got %v", err)
+	}
+}
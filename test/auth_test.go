@@ -2,6 +2,7 @@ package auth
 
 import (
 	auth "GoServer/internal/auth"
+	"net/url"
 	"testing"
 	"time"
 
@@ -75,3 +76,36 @@ func TestValidateJWTWithWrongSecret(t *testing.T) {
 		t.Fatalf("expected an error, got none")
 	}
 }
+
+func TestSignedURL(t *testing.T) {
+	now := time.Now()
+	link := auth.SignURL("/api/exports/123/download", "mysecret", now.Add(time.Hour))
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := auth.VerifySignedURL(u, "mysecret", now); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := auth.VerifySignedURL(u, "wrongsecret", now); err == nil {
+		t.Fatalf("expected an error for the wrong secret, got none")
+	}
+	if err := auth.VerifySignedURL(u, "mysecret", now.Add(2*time.Hour)); err == nil {
+		t.Fatalf("expected an error for an expired link, got none")
+	}
+
+	other := *u
+	other.Path = "/api/exports/456/download"
+	if err := auth.VerifySignedURL(&other, "mysecret", now); err == nil {
+		t.Fatalf("expected an error for another path, got none")
+	}
+
+	extended := *u
+	query := extended.Query()
+	query.Set("expires", "9999999999")
+	extended.RawQuery = query.Encode()
+	if err := auth.VerifySignedURL(&extended, "mysecret", now); err == nil {
+		t.Fatalf("expected an error for a changed expiry, got none")
+	}
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"GoServer/internal/entities"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	found := entities.Hashtags("Olá #GoLang e #golang! #1 a#b &#39; #ção_2")
+	expected := []entities.Entity{
+		{Type: entities.TypeHashtag, Text: "#GoLang", Value: "golang", Start: 4, End: 11},
+		{Type: entities.TypeHashtag, Text: "#golang", Value: "golang", Start: 14, End: 21},
+		{Type: entities.TypeHashtag, Text: "#ção_2", Value: "ção_2", Start: 36, End: 42},
+	}
+	if len(found) != len(expected) {
+		t.Fatalf("expected %d hashtags, got %+v", len(expected), found)
+	}
+	for i := range expected {
+		if found[i] != expected[i] {
+			t.Fatalf("expected %+v, got %+v", expected[i], found[i])
+		}
+	}
+
+	values := entities.UniqueValues(found)
+	if len(values) != 2 || values[0] != "golang" || values[1] != "ção_2" {
+		t.Fatalf("expected unique values [golang ção_2], got %v", values)
+	}
+}
+
+func TestMentions(t *testing.T) {
+	found := entities.Mentions("oi @Ana_1, fale com ana@exemplo.com e @joão ou @@bob")
+	if len(found) != 1 {
+		t.Fatalf("expected 1 mention, got %+v", found)
+	}
+	mention := found[0]
+	if mention.Text != "@Ana_1" || mention.Value != "ana_1" || mention.Start != 3 || mention.End != 9 {
+		t.Fatalf("unexpected mention %+v", mention)
+	}
+}
+
+func TestValidHandle(t *testing.T) {
+	for _, handle := range []string{"ana", "Bob_99"} {
+		if !entities.ValidHandle(handle) {
+			t.Fatalf("expected %q to be valid", handle)
+		}
+	}
+	for _, handle := range []string{"", "joão", "a-b", "this_handle_is_way_too_long_to_use"} {
+		if entities.ValidHandle(handle) {
+			t.Fatalf("expected %q to be invalid", handle)
+		}
+	}
+}
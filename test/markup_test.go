@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"GoServer/internal/entities"
+	"GoServer/internal/markup"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var testLinks = markup.Links{
+	Mention: func(handle string) (string, bool) {
+		return "/users/" + handle, handle == "ana"
+	},
+	Hashtag: func(tag string) string {
+		return "/tags/" + tag
+	},
+}
+
+func TestMarkupHTML(t *testing.T) {
+	for body, want := range map[string]string{
+		"plain text":                    "plain text",
+		"**bold** and *it* and _it_":    "<strong>bold</strong> and <em>it</em> and <em>it</em>",
+		"**bold _inside_**":             "<strong>bold <em>inside</em></strong>",
+		"`a **b** <c>`":                 "<code>a **b** &lt;c&gt;</code>",
+		"snake_case_name and 2 * 3 * 4": "snake_case_name and 2 * 3 * 4",
+		"[docs](https://example.com/a)": `<a href="https://example.com/a" rel="nofollow noopener noreferrer">docs</a>`,
+		"see https://example.com.":      `see <a href="https://example.com" rel="nofollow noopener noreferrer">https://example.com</a>.`,
+		"[x](javascript:alert(1))":      "[x](javascript:alert(1))",
+		"hi @ana and @bob":              `hi <a href="/users/ana" class="mention">@ana</a> and @bob`,
+		"#Go rocks":                     `<a href="/tags/go" class="hashtag">#Go</a> rocks`,
+		"`#notatag`":                    "<code>#notatag</code>",
+		"<script>alert('x')</script>":   "&lt;script&gt;alert(&#39;x&#39;)&lt;/script&gt;",
+		"line\nbreak":                   "line<br>break",
+		`[a"b](https://x.io/?q="><b>)`:  `<a href="https://x.io/?q=&#34;&gt;&lt;b&gt;" rel="nofollow noopener noreferrer">a&#34;b</a>`,
+		"[a](https://x.io/[b])":         `[a](<a href="https://x.io/[b" rel="nofollow noopener noreferrer">https://x.io/[b</a>])`,
+	} {
+		if got := markup.Parse(body).HTML(testLinks); got != want {
+			t.Errorf("%q: expected %q, got %q", body, want, got)
+		}
+	}
+}
+
+func TestMarkupEntities(t *testing.T) {
+	found := markup.Parse("é **#go** `@ana` [x](https://a.io)").Entities()
+	want := []entities.Entity{
+		{Type: entities.TypeBold, Text: "**#go**", Value: "#go", Start: 2, End: 9},
+		{Type: entities.TypeHashtag, Text: "#go", Value: "go", Start: 4, End: 7},
+		{Type: entities.TypeCode, Text: "`@ana`", Value: "@ana", Start: 10, End: 16},
+		{Type: entities.TypeLink, Text: "[x](https://a.io)", Value: "https://a.io", Start: 17, End: 34},
+	}
+	if len(found) != len(want) {
+		t.Fatalf("expected %d entities, got %+v", len(want), found)
+	}
+	for i := range want {
+		if found[i] != want[i] {
+			t.Errorf("entity %d: expected %+v, got %+v", i, want[i], found[i])
+		}
+	}
+}
+
+// TestMarkupLinearTime parses bodies made of openers that never close. Each
+// failed opener searching the rest of the body for a closer would take
+// seconds here.
+func TestMarkupLinearTime(t *testing.T) {
+	const n = 20000
+	for _, body := range []string{
+		strings.Repeat("[a](", n),
+		strings.Repeat("*a ", n) + "https://x.io/" + strings.Repeat("a", n),
+		strings.Repeat("_a ", n),
+		strings.Repeat("**a ", n),
+		strings.Repeat("`a", n) + "\n`",
+		strings.Repeat("[a](http://x.io/%zz", n) + ")",
+		strings.Repeat("/http://a%zz", n),
+	} {
+		start := time.Now()
+		markup.Parse(body).HTML(testLinks)
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%q...: took %s", body[:12], elapsed)
+		}
+	}
+}
+
+// allowedTag matches every tag the renderer may emit.
+var allowedTag = regexp.MustCompile(`^(<(/?)(strong|em|code)>|<br>|</a>|<a href="[^"<>]*"( class="(mention|hashtag)")?( rel="nofollow noopener noreferrer")?>)`)
+
+var hrefAttr = regexp.MustCompile(`<a href="([^"]*)"`)
+
+func FuzzMarkupHTML(f *testing.F) {
+	for _, seed := range []string{
+		"**bold** *it* _it_ `code`",
+		"[a](https://b.c) https://d.e/f?g=h#i",
+		"@ana #tag <b>&amp;</b>",
+		"***x*** **_y_** _*z*_ [**](http://x)",
+		"[x](javascript:alert(1)) <img src=x onerror=alert(1)>",
+		"\"'\n\x00‮",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, body string) {
+		if !utf8.ValidString(body) {
+			return
+		}
+		doc := markup.Parse(body)
+		out := doc.HTML(testLinks)
+		if again := markup.Parse(body).HTML(testLinks); again != out {
+			t.Fatalf("rendering is not deterministic: %q vs %q", out, again)
+		}
+
+		var open []string
+		for rest := out; rest != ""; {
+			i := strings.IndexByte(rest, '<')
+			if i < 0 {
+				break
+			}
+			if strings.ContainsAny(rest[:i], `>"`) {
+				t.Fatalf("unescaped text in %q", out)
+			}
+			m := allowedTag.FindStringSubmatch(rest[i:])
+			if m == nil {
+				t.Fatalf("disallowed markup in %q", out)
+			}
+			tag := m[3]
+			if tag == "" && strings.HasPrefix(m[0], "<a") {
+				tag = "a"
+			} else if m[0] == "</a>" {
+				tag, m[2] = "a", "/"
+			}
+			switch {
+			case tag == "":
+			case m[2] == "/":
+				if len(open) == 0 || open[len(open)-1] != tag {
+					t.Fatalf("unbalanced </%s> in %q", tag, out)
+				}
+				open = open[:len(open)-1]
+			default:
+				open = append(open, tag)
+			}
+			rest = rest[i+len(m[0]):]
+		}
+		if len(open) != 0 {
+			t.Fatalf("unclosed %v in %q", open, out)
+		}
+		for _, m := range hrefAttr.FindAllStringSubmatch(out, -1) {
+			href := strings.ToLower(m[1])
+			if !strings.HasPrefix(href, "http://") && !strings.HasPrefix(href, "https://") &&
+				!strings.HasPrefix(href, "/users/") && !strings.HasPrefix(href, "/tags/") {
+				t.Fatalf("link to an unexpected target in %q", out)
+			}
+		}
+
+		runes := []rune(body)
+		for _, entity := range doc.Entities() {
+			if entity.Start < 0 || entity.End > len(runes) || entity.Start >= entity.End {
+				t.Fatalf("entity out of range: %+v", entity)
+			}
+			if string(runes[entity.Start:entity.End]) != entity.Text {
+				t.Fatalf("entity text does not match the body: %+v", entity)
+			}
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"GoServer/internal/blobstore"
+	"GoServer/internal/media"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// withOrientation inserts an EXIF block carrying only an orientation tag
+// right after the JPEG start-of-image marker.
+func withOrientation(jpg []byte, orientation uint16) []byte {
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, 0xFF, 0xE1)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(segment)+2))
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func TestProcessRotatesAndStripsExif(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(40, 20), nil); err != nil {
+		t.Fatal(err)
+	}
+	processed, err := media.Process(withOrientation(buf.Bytes(), 6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed.ContentType != "image/jpeg" {
+		t.Fatalf("unexpected content type %q", processed.ContentType)
+	}
+	if processed.Width != 20 || processed.Height != 40 {
+		t.Fatalf("expected a 20x40 image, got %dx%d", processed.Width, processed.Height)
+	}
+	if bytes.Contains(processed.Data, []byte("Exif")) {
+		t.Fatalf("expected EXIF data to be stripped")
+	}
+}
+
+func TestProcessCreatesThumbnail(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(1000, 500)); err != nil {
+		t.Fatal(err)
+	}
+	processed, err := media.Process(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumb, err := png.Decode(bytes.NewReader(processed.Thumbnail))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thumb.Bounds().Dx() != media.ThumbnailSize || thumb.Bounds().Dy() != media.ThumbnailSize/2 {
+		t.Fatalf("unexpected thumbnail size %v", thumb.Bounds())
+	}
+}
+
+func TestProcessSniffsContent(t *testing.T) {
+	_, err := media.Process([]byte("<html><script>alert(1)</script></html>"))
+	if !errors.Is(err, media.ErrUnsupportedType) {
+		t.Fatalf("expected ErrUnsupportedType, got %v", err)
+	}
+}
+
+func testGIF(t *testing.T, w, h, frames int) []byte {
+	anim := &gif.GIF{}
+	for range frames {
+		anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, w, h), color.Palette{color.Black, color.White}))
+		anim.Delay = append(anim.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessKeepsAnimations(t *testing.T) {
+	processed, err := media.Process(testGIF(t, 30, 20, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(bytes.NewReader(processed.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anim.Image) != 3 || processed.Width != 30 || processed.Height != 20 {
+		t.Fatalf("expected 3 frames of 30x20, got %d of %dx%d", len(anim.Image), processed.Width, processed.Height)
+	}
+}
+
+func TestProcessRejectsHugeAnimations(t *testing.T) {
+	// 60 blank 1000x1000 frames compress to a few kilobytes but would
+	// decode to 60 megapixels
+	_, err := media.Process(testGIF(t, 1000, 1000, 60))
+	if err == nil || !strings.Contains(err.Error(), "animation is larger") {
+		t.Fatalf("expected the animation to be rejected, got %v", err)
+	}
+}
+
+// testGIFOnScreen is testGIF with frames of w x h in the top left corner of
+// a larger logical screen.
+func testGIFOnScreen(t *testing.T, screenW, screenH, w, h, frames int) []byte {
+	anim := &gif.GIF{Config: image.Config{Width: screenW, Height: screenH}}
+	for range frames {
+		anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, w, h), color.Palette{color.Black, color.White}))
+		anim.Delay = append(anim.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessMeasuresAnimationsByTheirScreen(t *testing.T) {
+	processed, err := media.Process(testGIFOnScreen(t, 30, 20, 10, 5, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed.Width != 30 || processed.Height != 20 {
+		t.Fatalf("expected 30x20, got %dx%d", processed.Width, processed.Height)
+	}
+
+	// 60 frames of a single pixel, each drawn onto a 1000x1000 screen
+	_, err = media.Process(testGIFOnScreen(t, 1000, 1000, 1, 1, 60))
+	if err == nil || !strings.Contains(err.Error(), "animation is larger") {
+		t.Fatalf("expected the animation to be rejected, got %v", err)
+	}
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := blobstore.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "a/b", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	blob, err := store.Get(ctx, "a/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(blob)
+	blob.Close()
+	if string(data) != "hello" {
+		t.Fatalf("unexpected blob %q", data)
+	}
+	if err := store.Delete(ctx, "a/b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "a/b"); !errors.Is(err, blobstore.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.Put(ctx, "../escape", strings.NewReader("x")); err == nil {
+		t.Fatalf("expected keys leaving the root to be rejected")
+	}
+}
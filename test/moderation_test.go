@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"GoServer/internal/moderation"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestDefaultPipelineMasksProfanity(t *testing.T) {
+	outcome := moderation.Default().Run("I had something interesting for breakfast. What a Kerfuffle!")
+	if outcome.Body != "I had something interesting for breakfast. What a ****!" {
+		t.Fatalf("unexpected body %q", outcome.Body)
+	}
+	if len(outcome.Decisions) != 1 || outcome.Decisions[0].Rule != "profanity" {
+		t.Fatalf("expected the profanity rule to fire, got %+v", outcome.Decisions)
+	}
+}
+
+func TestPipelineStopsOnReject(t *testing.T) {
+	pipeline := moderation.Pipeline{
+		moderation.Regex{RuleName: "phone", Pattern: regexp.MustCompile(`\d{9}`), RuleAction: moderation.ActionFlag},
+		moderation.LinkDomains{RuleName: "spam", Domains: []string{"spam.example"}, RuleAction: moderation.ActionReject},
+		moderation.WordList{RuleName: "never", Words: []string{"buy"}, RuleAction: moderation.ActionMask},
+	}
+	outcome := pipeline.Run("call 123456789 and buy at https://www.spam.example/deal")
+	if outcome.Rejected == nil || outcome.Rejected.Rule != "spam" {
+		t.Fatalf("expected the spam rule to reject, got %+v", outcome)
+	}
+	if !outcome.Flagged() {
+		t.Fatalf("expected the phone rule to flag")
+	}
+	if len(outcome.Decisions) != 2 {
+		t.Fatalf("expected the pipeline to stop at the rejecting rule, got %+v", outcome.Decisions)
+	}
+}
+
+func TestLinkDomainsIgnoresOtherDomains(t *testing.T) {
+	f := moderation.LinkDomains{RuleName: "spam", Domains: []string{"spam.example"}, RuleAction: moderation.ActionMask}
+	body, fired := f.Filter("see https://notspam.example and https://spam.example.org")
+	if fired {
+		t.Fatalf("expected no match, got %q", body)
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	rules := `[{"type": "words", "name": "profanity", "action": "mask", "words": ["fornax"]},
+		{"type": "regex", "name": "shouting", "action": "flag", "pattern": "[A-Z]{10,}"}]`
+	if err := os.WriteFile(path, []byte(rules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	pipeline, err := moderation.Load(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(pipeline) != 2 || pipeline[1].Name() != "shouting" {
+		t.Fatalf("unexpected pipeline %+v", pipeline)
+	}
+
+	if err := os.WriteFile(path, []byte(`[{"type": "words", "name": "x", "action": "explode"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := moderation.Load(path); err == nil {
+		t.Fatalf("expected an error for an invalid action, got none")
+	}
+}
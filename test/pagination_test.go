@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"GoServer/internal/pagination"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type row struct {
+	at time.Time
+	id uuid.UUID
+}
+
+func rowCursor(r row) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: r.at, ID: r.id}
+}
+
+func makeRows(n int) []row {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := make([]row, n)
+	for i := range rows {
+		rows[i] = row{at: start.Add(time.Duration(i) * time.Minute), id: uuid.New()}
+	}
+	return rows
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := pagination.Cursor{CreatedAt: time.Now().UTC(), ID: uuid.New(), Backward: true}
+	decoded, err := pagination.DecodeCursor(c.Encode())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !decoded.CreatedAt.Equal(c.CreatedAt) || decoded.ID != c.ID || !decoded.Backward {
+		t.Fatalf("expected %+v, got %+v", c, decoded)
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	if _, err := pagination.DecodeCursor("not-a-cursor"); err == nil {
+		t.Fatalf("expected an error, got none")
+	}
+}
+
+func TestParseParamsRejectsBadLimit(t *testing.T) {
+	for _, limit := range []string{"0", "-1", "abc", "101"} {
+		if _, err := pagination.ParseParams(url.Values{"limit": {limit}}); err == nil {
+			t.Fatalf("expected an error for limit %q, got none", limit)
+		}
+	}
+}
+
+func TestWindowFirstPage(t *testing.T) {
+	rows := makeRows(4)
+	p := pagination.Params{Limit: 3}
+	page, next, prev := pagination.Window(rows, p, rowCursor)
+	if len(page) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(page))
+	}
+	if next == "" || prev != "" {
+		t.Fatalf("expected only a next cursor, got next=%q prev=%q", next, prev)
+	}
+	c, _ := pagination.DecodeCursor(next)
+	if c.ID != rows[2].id || c.Backward {
+		t.Fatalf("expected next cursor at the last row of the page")
+	}
+}
+
+func TestWindowBackwardPage(t *testing.T) {
+	rows := makeRows(3)
+	before := pagination.Cursor{CreatedAt: rows[2].at, ID: rows[2].id, Backward: true}
+	p := pagination.Params{Limit: 2, Cursor: &before}
+	// backward pages are fetched in reverse keyset order
+	fetched := []row{rows[1], rows[0]}
+	page, next, prev := pagination.Window(fetched, p, rowCursor)
+	if page[0].id != rows[0].id || page[1].id != rows[1].id {
+		t.Fatalf("expected backward page in display order")
+	}
+	if next == "" {
+		t.Fatalf("expected a next cursor")
+	}
+	if prev != "" {
+		t.Fatalf("expected no prev cursor on the first page, got %q", prev)
+	}
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"GoServer/internal/search"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	cases := map[string]string{
+		"go server":             "go & server",
+		`"hello world" chirp*`:  "(hello <-> world) & chirp:*",
+		"Olá!  MUNDO":           "olá & mundo",
+		`drop' | !table & ":*"`: "drop & table",
+	}
+	for input, expected := range cases {
+		got, err := search.ParseQuery(input)
+		if err != nil {
+			t.Fatalf("expected no error for %q, got %v", input, err)
+		}
+		if got != expected {
+			t.Fatalf("expected %q for %q, got %q", expected, input, got)
+		}
+	}
+}
+
+func TestParseQueryWithoutTerms(t *testing.T) {
+	if _, err := search.ParseQuery(`  "" * & `); err == nil {
+		t.Fatalf("expected an error, got none")
+	}
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"GoServer/internal/textlen"
+	"strings"
+	"testing"
+)
+
+func TestGraphemes(t *testing.T) {
+	cases := []struct {
+		input string
+		want  int
+	}{
+		{"hello", 5},
+		{"ação é", 6},
+		{"açã́o", 4},
+		{"👍🏽", 1},
+		{"👨‍👩‍👧‍👦", 1},
+		{"🇧🇷🇵🇹", 2},
+		{"🇧🇷🇵", 2},
+		{"한국어", 3},
+		{"각", 1},
+		{"a\r\nb", 3},
+		{"❤️", 1},
+	}
+	for _, c := range cases {
+		if got := textlen.Graphemes(c.input); got != c.want {
+			t.Errorf("Graphemes(%q) = %d, want %d", c.input, got, c.want)
+		}
+	}
+}
+
+func TestChirpLengthWeighsURLs(t *testing.T) {
+	short := "see https://a.io"
+	long := "see https://example.com/" + strings.Repeat("x", 200)
+	if got := textlen.ChirpLength(short); got != 4+textlen.URLWeight {
+		t.Fatalf("unexpected length %d", got)
+	}
+	if textlen.ChirpLength(short) != textlen.ChirpLength(long) {
+		t.Fatalf("expected every URL to count the same")
+	}
+}
+
+func TestPortugueseChirpFitsDefaultLimit(t *testing.T) {
+	body := strings.Repeat("ção", 46) + "!!"
+	if len(body) <= 140 {
+		t.Fatalf("test body should be longer than 140 bytes")
+	}
+	if got := textlen.ChirpLength(body); got != 140 {
+		t.Fatalf("expected 140 characters, got %d", got)
+	}
+}
+
+func TestFitsCapsBytes(t *testing.T) {
+	marks := "a" + strings.Repeat("\u0301", 2000)
+	if textlen.ChirpLength(marks) != 1 {
+		t.Fatalf("expected combining marks to join one character")
+	}
+	if textlen.Fits(marks, 140) {
+		t.Fatalf("expected a %d-byte body to exceed the byte ceiling", len(marks))
+	}
+	url := "https://example.com/" + strings.Repeat("x", 1<<20)
+	if textlen.Fits(url, 140) {
+		t.Fatalf("expected a 1 MB link to exceed the byte ceiling")
+	}
+	if !textlen.Fits(strings.Repeat("👨‍👩‍👧‍👦", 40), 140) {
+		t.Fatalf("expected 40 family emoji to fit 140 characters")
+	}
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/timeline"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// pullTimelineQuery reads a timeline page the way it was read before
+// timelines were stored: one range of the chirps index per followed account.
+const pullTimelineQuery = `
+SELECT id, created_at FROM chirps
+WHERE id IN (
+    SELECT recent.id
+    FROM (
+        SELECT followee_id AS author_id FROM follows WHERE follower_id = $1
+        UNION ALL
+        SELECT $1
+    ) AS authors
+    CROSS JOIN LATERAL (
+        SELECT chirps.id FROM chirps
+        WHERE chirps.user_id = authors.author_id
+          AND chirps.deleted_at IS NULL
+          AND chirps.publish_at IS NULL
+          AND chirp_visible(chirps.id, chirps.user_id, chirps.visibility, chirps.publish_at, $1, true)
+        ORDER BY chirps.created_at DESC, chirps.id DESC
+        LIMIT $2
+    ) AS recent
+)
+ORDER BY created_at DESC, id DESC
+LIMIT $2`
+
+// seedTimeline creates a viewer following the given number of accounts with
+// perAuthor chirps each, the first celebrities of which are merged on read,
+// and builds the viewer's stored timeline. cleanup deletes it all again.
+func seedTimeline(b *testing.B, db *sql.DB, following, celebrities, perAuthor, maxLength int) (viewer uuid.UUID, cleanup func()) {
+	ctx := context.Background()
+	q := database.New(db)
+
+	var ids []uuid.UUID
+	rows, err := db.QueryContext(ctx, `
+INSERT INTO users (id, created_at, updated_at, email)
+SELECT gen_random_uuid(), NOW(), NOW(), 'timeline-bench-' || gen_random_uuid() || '@example.com'
+FROM generate_series(0, $1)
+RETURNING id`, following)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			b.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		b.Fatal(err)
+	}
+	cleanup = func() {
+		if _, err := db.ExecContext(ctx, `DELETE FROM users WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+			b.Error(err)
+		}
+	}
+	viewer, authors := ids[0], ids[1:]
+
+	for _, author := range authors {
+		if _, err := q.FollowUser(ctx, database.FollowUserParams{FollowerID: viewer, FolloweeID: author}); err != nil {
+			cleanup()
+			b.Fatal(err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, `
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, pull_on_read)
+SELECT gen_random_uuid(), NOW() - random() * interval '30 days', NOW(), 'benchmark chirp', authors.id, authors.position <= $2
+FROM unnest($1::uuid[]) WITH ORDINALITY AS authors (id, position)
+CROSS JOIN generate_series(1, $3)`, pq.Array(authors), celebrities, perAuthor); err != nil {
+		cleanup()
+		b.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE users SET has_pulled_chirps = true WHERE id = ANY($1)`, pq.Array(authors[:celebrities])); err != nil {
+		cleanup()
+		b.Fatal(err)
+	}
+	for _, author := range authors[celebrities:] {
+		if err := q.BackfillTimeline(ctx, database.BackfillTimelineParams{UserID: viewer, AuthorID: author, RowLimit: int32(maxLength)}); err != nil {
+			cleanup()
+			b.Fatal(err)
+		}
+	}
+	if err := q.TrimTimeline(ctx, database.TrimTimelineParams{UserID: viewer, MaxLength: int32(maxLength)}); err != nil {
+		cleanup()
+		b.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, `ANALYZE users, follows, chirps, timeline_entries`); err != nil {
+		cleanup()
+		b.Fatal(err)
+	}
+	return viewer, cleanup
+}
+
+// BenchmarkTimelineQueries compares the first page of a timeline read with
+// the pull query against the stored timeline read of getTimeline, on a
+// seeded database. It needs TIMELINE_BENCH_DB_URL to point at a migrated
+// database and only ever deletes the rows it created.
+func BenchmarkTimelineQueries(b *testing.B) {
+	dbURL := os.Getenv("TIMELINE_BENCH_DB_URL")
+	if dbURL == "" {
+		b.Skip("TIMELINE_BENCH_DB_URL is not set")
+	}
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	q := database.New(db)
+	ctx := context.Background()
+
+	const limit, perAuthor, maxLength = 21, 50, 800
+	for _, following := range []int{10, 100, 1000, 5000} {
+		viewer, cleanup := seedTimeline(b, db, following, min(following/100, 5), perAuthor, maxLength)
+
+		b.Run(fmt.Sprintf("pull/following=%d", following), func(b *testing.B) {
+			for b.Loop() {
+				rows, err := db.QueryContext(ctx, pullTimelineQuery, viewer, limit)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for rows.Next() {
+				}
+				if err := rows.Close(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("push/following=%d", following), func(b *testing.B) {
+			for b.Loop() {
+				stored, err := q.ListTimelineEntriesBefore(ctx, database.ListTimelineEntriesBeforeParams{ViewerID: viewer, RowLimit: limit})
+				if err != nil {
+					b.Fatal(err)
+				}
+				pulled, err := q.ListFollowedChirpsBefore(ctx, database.ListFollowedChirpsBeforeParams{ViewerID: viewer, RowLimit: limit})
+				if err != nil {
+					b.Fatal(err)
+				}
+				sources := make([][]timeline.Entry, 2)
+				for _, row := range stored {
+					sources[0] = append(sources[0], timeline.Entry{ChirpID: row.ID, CreatedAt: row.CreatedAt})
+				}
+				for _, row := range pulled {
+					sources[1] = append(sources[1], timeline.Entry{ChirpID: row.ID, CreatedAt: row.CreatedAt})
+				}
+				timeline.Merge(limit, true, sources...)
+			}
+		})
+
+		cleanup()
+	}
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"GoServer/internal/timeline"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var timelineEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func timelineEntry(minute int) timeline.Entry {
+	return timeline.Entry{ChirpID: uuid.New(), CreatedAt: timelineEpoch.Add(time.Duration(minute) * time.Minute)}
+}
+
+func TestMergeTimelinesNewestFirst(t *testing.T) {
+	a := []timeline.Entry{timelineEntry(9), timelineEntry(5), timelineEntry(1)}
+	b := []timeline.Entry{timelineEntry(8), a[1], timelineEntry(2)}
+
+	merged := timeline.Merge(10, true, a, b, nil)
+	want := []timeline.Entry{a[0], b[0], a[1], b[2], a[2]}
+	if len(merged) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), merged)
+	}
+	for i := range want {
+		if merged[i] != want[i] {
+			t.Fatalf("entry %d: expected %+v, got %+v", i, want[i], merged[i])
+		}
+	}
+}
+
+func TestMergeTimelinesAscendingWithLimit(t *testing.T) {
+	a := []timeline.Entry{timelineEntry(1), timelineEntry(4)}
+	b := []timeline.Entry{timelineEntry(2), timelineEntry(3)}
+
+	merged := timeline.Merge(3, false, a, b)
+	want := []timeline.Entry{a[0], b[0], b[1]}
+	if len(merged) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), merged)
+	}
+	for i := range want {
+		if merged[i] != want[i] {
+			t.Fatalf("entry %d: expected %+v, got %+v", i, want[i], merged[i])
+		}
+	}
+}
+
+func TestMergeTimelinesBreaksTiesByID(t *testing.T) {
+	low := timeline.Entry{ChirpID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), CreatedAt: timelineEpoch}
+	high := timeline.Entry{ChirpID: uuid.MustParse("ff000000-0000-0000-0000-000000000000"), CreatedAt: timelineEpoch}
+
+	merged := timeline.Merge(2, true, []timeline.Entry{low}, []timeline.Entry{high})
+	if len(merged) != 2 || merged[0] != high || merged[1] != low {
+		t.Fatalf("expected the higher ID first, got %+v", merged)
+	}
+}
+
+// timelineIndex models a (key, created_at, id) index: the entries of each
+// key in ascending order.
+type timelineIndex map[uuid.UUID][]timeline.Entry
+
+// newest reads up to limit entries of key older than before, newest first,
+// the way the timeline queries range-scan the index: one seek per key, then
+// a walk of at most limit entries.
+func (idx timelineIndex) newest(key uuid.UUID, before timeline.Entry, limit int) []timeline.Entry {
+	entries := idx[key]
+	end := sort.Search(len(entries), func(i int) bool { return !timeline.Less(entries[i], before) })
+	start := max(end-limit, 0)
+	page := make([]timeline.Entry, 0, end-start)
+	for i := end - 1; i >= start; i-- {
+		page = append(page, entries[i])
+	}
+	return page
+}
+
+// timelineGraph is a viewer following accounts that each posted perAuthor
+// chirps, the first celebrities of which had their chirps merged on read.
+type timelineGraph struct {
+	viewer    uuid.UUID
+	following []uuid.UUID
+	pulled    map[uuid.UUID]bool
+	// chirps is the (user_id, created_at, id) index of all chirps, stored the
+	// viewer's stored timeline and pullOnRead the pull_on_read index.
+	chirps, stored, pullOnRead timelineIndex
+}
+
+func newTimelineGraph(following, celebrities, perAuthor, maxLength int) timelineGraph {
+	rng := rand.New(rand.NewPCG(1, 2))
+	g := timelineGraph{
+		viewer:     uuid.New(),
+		pulled:     map[uuid.UUID]bool{},
+		chirps:     timelineIndex{},
+		stored:     timelineIndex{},
+		pullOnRead: timelineIndex{},
+	}
+	var fannedOut []timeline.Entry
+	for i := range following {
+		author := uuid.New()
+		g.following = append(g.following, author)
+		entries := make([]timeline.Entry, perAuthor)
+		for j := range entries {
+			entries[j] = timelineEntry(-rng.IntN(perAuthor * 60))
+		}
+		sort.Slice(entries, func(a, b int) bool { return timeline.Less(entries[a], entries[b]) })
+		g.chirps[author] = entries
+		if i < celebrities {
+			g.pulled[author] = true
+			g.pullOnRead[author] = entries
+		} else {
+			fannedOut = append(fannedOut, entries...)
+		}
+	}
+	sort.Slice(fannedOut, func(a, b int) bool { return timeline.Less(fannedOut[a], fannedOut[b]) })
+	g.stored[g.viewer] = fannedOut[max(len(fannedOut)-maxLength, 0):]
+	return g
+}
+
+// pull reads a page the way the pull timeline query did: one range of the
+// chirps index per followed account, merged.
+func (g timelineGraph) pull(before timeline.Entry, limit int) []timeline.Entry {
+	sources := make([][]timeline.Entry, 0, len(g.following))
+	for _, author := range g.following {
+		sources = append(sources, g.chirps.newest(author, before, limit))
+	}
+	return timeline.Merge(limit, true, sources...)
+}
+
+// push reads a page the way getTimeline does: one range of the stored
+// timeline, plus one range of the pull_on_read index per followed account
+// that has pulled chirps.
+func (g timelineGraph) push(before timeline.Entry, limit int) []timeline.Entry {
+	sources := [][]timeline.Entry{g.stored.newest(g.viewer, before, limit)}
+	for _, author := range g.following {
+		if g.pulled[author] {
+			sources = append(sources, g.pullOnRead.newest(author, before, limit))
+		}
+	}
+	return timeline.Merge(limit, true, sources...)
+}
+
+func TestTimelinePushMatchesPull(t *testing.T) {
+	g := newTimelineGraph(50, 3, 20, 800)
+	before := timelineEntry(1)
+	for range 5 {
+		pulled, pushed := g.pull(before, 21), g.push(before, 21)
+		if len(pulled) != 21 || len(pushed) != len(pulled) {
+			t.Fatalf("expected 21 entries from both, got %d and %d", len(pulled), len(pushed))
+		}
+		for i := range pulled {
+			if pulled[i] != pushed[i] {
+				t.Fatalf("entry %d: pull read %+v, push read %+v", i, pulled[i], pushed[i])
+			}
+		}
+		before = pulled[len(pulled)-1]
+	}
+}
+
+// BenchmarkTimelineModel compares reading the first page of a timeline by
+// pulling from every followed account with reading the stored timeline, on
+// an in-memory model of the indexes the queries scan. It counts index seeks
+// and row reads, not the cost of a Postgres round trip; see
+// BenchmarkTimelineQueries for that.
+func BenchmarkTimelineModel(b *testing.B) {
+	const limit, perAuthor, maxLength = 21, 50, 800
+	before := timelineEntry(1)
+	for _, following := range []int{10, 100, 1000, 5000} {
+		g := newTimelineGraph(following, min(following/100, 5), perAuthor, maxLength)
+		b.Run(fmt.Sprintf("pull/following=%d", following), func(b *testing.B) {
+			for b.Loop() {
+				g.pull(before, limit)
+			}
+		})
+		b.Run(fmt.Sprintf("push/following=%d", following), func(b *testing.B) {
+			for b.Loop() {
+				g.push(before, limit)
+			}
+		})
+	}
+}
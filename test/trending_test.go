@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"GoServer/internal/trending"
+	"testing"
+	"time"
+)
+
+func TestScoreDecaysOlderUses(t *testing.T) {
+	w := trending.Window{Name: "1h", Length: time.Hour, HalfLife: 15 * time.Minute}
+	usages := []trending.Usage{
+		{Tag: "old", AgeBucket: 40, Uses: 5},
+		{Tag: "new", AgeBucket: 0, Uses: 3},
+		{Tag: "expired", AgeBucket: 60, Uses: 100},
+	}
+
+	trends := trending.Score(usages, w, 10)
+	if len(trends) != 2 {
+		t.Fatalf("expected 2 trends, got %+v", trends)
+	}
+	if trends[0].Tag != "new" || trends[1].Tag != "old" {
+		t.Fatalf("expected recent uses to rank first, got %+v", trends)
+	}
+	if trends[1].Uses != 5 {
+		t.Fatalf("expected 5 uses, got %d", trends[1].Uses)
+	}
+}
+
+func TestScoreLimit(t *testing.T) {
+	w := trending.DefaultWindows[1]
+	usages := []trending.Usage{{Tag: "a", Uses: 1}, {Tag: "b", Uses: 2}, {Tag: "c", Uses: 3}}
+	trends := trending.Score(usages, w, 2)
+	if len(trends) != 2 || trends[0].Tag != "c" {
+		t.Fatalf("expected top 2 trends led by c, got %+v", trends)
+	}
+}
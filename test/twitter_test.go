@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"GoServer/internal/twitter"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+const tweetsJS = `window.YTD.tweets.part0 = [
+  {
+    "tweet" : {
+      "id_str" : "200",
+      "created_at" : "Tue Mar 05 10:00:00 +0000 2019",
+      "full_text" : "RT @someone: a retweet",
+      "entities" : { "urls" : [ ], "media" : [ ] }
+    }
+  },
+  {
+    "tweet" : {
+      "id_str" : "100",
+      "created_at" : "Mon Mar 04 09:30:00 +0000 2019",
+      "full_text" : "Read this &amp; that https://t.co/abc https://t.co/pic",
+      "in_reply_to_status_id_str" : "50",
+      "entities" : {
+        "urls" : [ { "url" : "https://t.co/abc", "expanded_url" : "https://example.com/post" } ],
+        "media" : [ { "url" : "https://t.co/pic", "media_url_https" : "https://pbs.twimg.com/media/cat.jpg", "type" : "photo" } ]
+      },
+      "extended_entities" : {
+        "media" : [ { "url" : "https://t.co/pic", "media_url_https" : "https://pbs.twimg.com/media/cat.jpg", "type" : "photo" } ]
+      }
+    }
+  }
+]`
+
+func twitterArchive(t *testing.T, files map[string]string) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestTwitterArchive(t *testing.T) {
+	r := twitterArchive(t, map[string]string{
+		"data/tweets.js":                tweetsJS,
+		"data/tweets_media/100-cat.jpg": "jpeg data",
+	})
+	a, err := twitter.Open(r, r.Size())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(a.Tweets) != 2 {
+		t.Fatalf("expected 2 tweets, got %d", len(a.Tweets))
+	}
+
+	tweet := a.Tweets[0]
+	if tweet.ID != "100" {
+		t.Fatalf("expected the oldest tweet first, got %s", tweet.ID)
+	}
+	if want := time.Date(2019, 3, 4, 9, 30, 0, 0, time.UTC); !tweet.CreatedAt.Equal(want) {
+		t.Fatalf("expected created_at %v, got %v", want, tweet.CreatedAt)
+	}
+	if want := "Read this & that https://example.com/post"; tweet.Text != want {
+		t.Fatalf("expected text %q, got %q", want, tweet.Text)
+	}
+	if tweet.InReplyToID != "50" || tweet.RetweetOf != "" {
+		t.Fatalf("unexpected reply or retweet fields: %+v", tweet)
+	}
+	if len(tweet.Media) != 1 || tweet.Media[0].Type != "photo" || tweet.Media[0].File != "data/tweets_media/100-cat.jpg" {
+		t.Fatalf("unexpected media: %+v", tweet.Media)
+	}
+	data, err := a.ReadMedia(tweet.Media[0].File, 1<<10)
+	if err != nil || string(data) != "jpeg data" {
+		t.Fatalf("expected the media data, got %q (%v)", data, err)
+	}
+
+	if a.Tweets[1].RetweetOf != "someone" {
+		t.Fatalf("expected a retweet of someone, got %q", a.Tweets[1].RetweetOf)
+	}
+}
+
+func TestTwitterArchiveFindsRetweetedTweets(t *testing.T) {
+	r := twitterArchive(t, map[string]string{
+		"data/account.js": `window.YTD.account.part0 = [ { "account" : { "username" : "Me" } } ]`,
+		"data/tweets.js": `window.YTD.tweets.part0 = [
+  { "tweet" : { "id_str" : "1", "created_at" : "Sat Jan 01 00:00:00 +0000 2011", "full_text" : "my own words" } },
+  { "tweet" : { "id_str" : "2", "created_at" : "Sun Jan 02 00:00:00 +0000 2011", "full_text" : "RT @me: my own words" } },
+  { "tweet" : { "id_str" : "3", "created_at" : "Mon Jan 03 00:00:00 +0000 2011", "full_text" : "RT @someone: my own words" } },
+  { "tweet" : { "id_str" : "4", "created_at" : "Tue Jan 04 00:00:00 +0000 2011", "full_text" : "RT @someone: theirs", "retweeted_status" : { "id_str" : "99" } } },
+  { "tweet" : { "id_str" : "5", "created_at" : "Wed Jan 05 00:00:00 +0000 2011", "full_text" : "RT @me: deleted since" } }
+]`,
+	})
+	a, err := twitter.Open(r, r.Size())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := map[string]string{"1": "", "2": "1", "3": "", "4": "99", "5": ""}
+	for _, tweet := range a.Tweets {
+		if tweet.RetweetedID != want[tweet.ID] {
+			t.Errorf("tweet %s: expected retweeted ID %q, got %q", tweet.ID, want[tweet.ID], tweet.RetweetedID)
+		}
+	}
+}
+
+func TestTwitterArchiveOldFormat(t *testing.T) {
+	r := twitterArchive(t, map[string]string{
+		"data/tweet.js":       `window.YTD.tweet.part0 = [ { "id_str" : "1", "created_at" : "Sat Jan 01 00:00:00 +0000 2011", "full_text" : "hello" } ]`,
+		"data/tweet-part1.js": `window.YTD.tweet.part1 = [ { "id_str" : "2", "created_at" : "Sun Jan 02 00:00:00 +0000 2011", "full_text" : "again" } ]`,
+	})
+	a, err := twitter.Open(r, r.Size())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(a.Tweets) != 2 || a.Tweets[0].Text != "hello" || a.Tweets[1].Text != "again" {
+		t.Fatalf("unexpected tweets: %+v", a.Tweets)
+	}
+}
+
+func TestTwitterArchiveInvalid(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"no tweets":    {"README.txt": "hello"},
+		"bad js":       {"data/tweets.js": "window.YTD.tweets.part0 = [ {"},
+		"bad date":     {"data/tweets.js": `x = [ { "id_str" : "1", "created_at" : "yesterday", "full_text" : "hi" } ]`},
+		"missing id":   {"data/tweets.js": `x = [ { "created_at" : "Sat Jan 01 00:00:00 +0000 2011", "full_text" : "hi" } ]`},
+		"not a script": {"data/tweets.js": `[]`},
+	} {
+		r := twitterArchive(t, files)
+		if _, err := twitter.Open(r, r.Size()); !errors.Is(err, twitter.ErrInvalid) {
+			t.Errorf("%s: expected ErrInvalid, got %v", name, err)
+		}
+	}
+}
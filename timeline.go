@@ -0,0 +1,125 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"GoServer/internal/pagination"
+	"GoServer/internal/timeline"
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// getTimeline serves the caller's home timeline: their own chirps and those
+// of the accounts they follow, newest first. Most of it is read from the
+// caller's stored timeline, which the fan-out worker fills; the chirps the
+// worker skipped because their author had too many followers are pulled per
+// followed account off the small pull_on_read index and merged in.
+func (cfg *apiConfig) getTimeline(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	page, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	entries, err := cfg.listTimeline(r.Context(), userID, page)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving timeline", err)
+		return
+	}
+	entries, nextCursor, prevCursor := pagination.Window(entries, page, func(entry timeline.Entry) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: entry.CreatedAt, ID: entry.ChirpID}
+	})
+	ids := make([]uuid.UUID, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ChirpID
+	}
+	chirps, err := cfg.getChirpsInOrder(r.Context(), userID, ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving timeline", err)
+		return
+	}
+	chirpsResponse, err := cfg.buildChirps(r.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving timeline", err)
+		return
+	}
+
+	pagination.SetLinkHeader(w, r, nextCursor, prevCursor)
+	respondWithJSON(w, http.StatusOK, pagination.Page[Chirp]{
+		Items:      chirpsResponse,
+		NextCursor: nextCursor,
+		PrevCursor: prevCursor,
+	})
+}
+
+// listTimeline fetches up to page.Limit+1 timeline entries past the page
+// cursor in query order, merging the stored timeline with the pull_on_read
+// chirps of followed accounts.
+func (cfg *apiConfig) listTimeline(ctx context.Context, userID uuid.UUID, page pagination.Params) ([]timeline.Entry, error) {
+	limit := int32(page.Limit + 1)
+	var stored, pulled []timeline.Entry
+	descending := page.Descending(true)
+	if descending {
+		cursorCreatedAt, cursorID := sql.NullTime{}, uuid.NullUUID{}
+		if page.Cursor != nil {
+			cursorCreatedAt = sql.NullTime{Time: page.Cursor.CreatedAt, Valid: true}
+			cursorID = uuid.NullUUID{UUID: page.Cursor.ID, Valid: true}
+		}
+		storedRows, err := cfg.DB.ListTimelineEntriesBefore(ctx, database.ListTimelineEntriesBeforeParams{
+			ViewerID:        userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			RowLimit:        limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range storedRows {
+			stored = append(stored, timeline.Entry{ChirpID: row.ID, CreatedAt: row.CreatedAt})
+		}
+		pulledRows, err := cfg.DB.ListFollowedChirpsBefore(ctx, database.ListFollowedChirpsBeforeParams{
+			ViewerID:        userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			RowLimit:        limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range pulledRows {
+			pulled = append(pulled, timeline.Entry{ChirpID: row.ID, CreatedAt: row.CreatedAt})
+		}
+	} else {
+		storedRows, err := cfg.DB.ListTimelineEntriesAfter(ctx, database.ListTimelineEntriesAfterParams{
+			ViewerID:        userID,
+			CursorCreatedAt: page.Cursor.CreatedAt,
+			CursorID:        page.Cursor.ID,
+			RowLimit:        limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range storedRows {
+			stored = append(stored, timeline.Entry{ChirpID: row.ID, CreatedAt: row.CreatedAt})
+		}
+		pulledRows, err := cfg.DB.ListFollowedChirpsAfter(ctx, database.ListFollowedChirpsAfterParams{
+			ViewerID:        userID,
+			CursorCreatedAt: page.Cursor.CreatedAt,
+			CursorID:        page.Cursor.ID,
+			RowLimit:        limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range pulledRows {
+			pulled = append(pulled, timeline.Entry{ChirpID: row.ID, CreatedAt: row.CreatedAt})
+		}
+	}
+	return timeline.Merge(int(limit), descending, stored, pulled), nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"GoServer/internal/database"
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fanoutBatchSize is how many queued chirps one fan-out transaction claims.
+const fanoutBatchSize = 100
+
+// runFanout copies newly published chirps into their readers' stored
+// timelines each interval until ctx is done. Every server may run one: jobs
+// are claimed with SKIP LOCKED.
+func (cfg *apiConfig) runFanout(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			fanned, err := cfg.fanOutChirps(ctx)
+			if err != nil {
+				log.Printf("Error fanning out chirps: %s", err)
+				break
+			}
+			if fanned < fanoutBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fanOutChirps fans out one batch of queued chirps. A chirp goes to its
+// author and, unless the author has more than TimelineFanoutMaxFollowers
+// followers, to every follower. Chirps of larger accounts are marked
+// pull_on_read instead and merged into timelines on read, whatever the
+// author's follower count later becomes.
+func (cfg *apiConfig) fanOutChirps(ctx context.Context) (int, error) {
+	var fanned int
+	err := cfg.withTx(ctx, func(q *database.Queries) error {
+		chirpIDs, err := q.ClaimFanoutJobs(ctx, fanoutBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, chirpID := range chirpIDs {
+			if err := q.MarkChirpPullOnRead(ctx, database.MarkChirpPullOnReadParams{
+				MaxFollowers: int32(cfg.TimelineFanoutMaxFollowers),
+				ChirpID:      chirpID,
+			}); err != nil {
+				return err
+			}
+			if _, err := q.FanOutChirp(ctx, chirpID); err != nil {
+				return err
+			}
+		}
+		fanned = len(chirpIDs)
+		return nil
+	})
+	return fanned, err
+}
+
+// runTimelineTrimmer cuts stored timelines back to TimelineMaxLength entries
+// each interval until ctx is done. Fan-out only ever appends, so timelines
+// grow past the limit in between.
+func (cfg *apiConfig) runTimelineTrimmer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := cfg.DB.TrimTimelines(ctx, int32(cfg.TimelineMaxLength)); err != nil {
+			log.Printf("Error trimming timelines: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// backfillTimeline copies the latest fanned-out chirps of a newly followed
+// account into the follower's stored timeline. Its pull_on_read chirps are
+// merged on read.
+func (cfg *apiConfig) backfillTimeline(ctx context.Context, q *database.Queries, userID, authorID uuid.UUID) error {
+	if err := q.BackfillTimeline(ctx, database.BackfillTimelineParams{
+		UserID:   userID,
+		AuthorID: authorID,
+		RowLimit: int32(cfg.TimelineMaxLength),
+	}); err != nil {
+		return err
+	}
+	return q.TrimTimeline(ctx, database.TrimTimelineParams{UserID: userID, MaxLength: int32(cfg.TimelineMaxLength)})
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"GoServer/internal/archive"
+	"GoServer/internal/twitter"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// Why a retweet was left out of an import: retweets are skipped unless
+// asked for, and can only be quoted when the retweeted tweet was imported.
+const (
+	skipRetweet       = "retweet"
+	skipRetweetTarget = "retweet_target_missing"
+)
+
+type ImportedTweet struct {
+	TweetID string    `json:"tweet_id"`
+	ChirpID uuid.UUID `json:"chirp_id"`
+}
+
+type SkippedTweet struct {
+	TweetID string `json:"tweet_id"`
+	Reason  string `json:"reason"`
+}
+
+type TwitterImportResult struct {
+	Imported []ImportedTweet `json:"imported"`
+	Skipped  []SkippedTweet  `json:"skipped"`
+}
+
+// tweetChirpID is the archive ID a tweet is imported under. It is derived
+// from the tweet, so importing an archive again finds the tweets already
+// imported and replies can name their parents before they are imported.
+func tweetChirpID(userID uuid.UUID, tweetID string) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://twitter.com/i/status/"+tweetID+"#"+userID.String()))
+}
+
+// importTwitterArchive re-creates the tweets of a Twitter/X archive as
+// chirps of the caller, keeping their dates. Retweets are skipped unless the
+// retweets form field is "quote", in which case a retweet of a tweet that is
+// in the archive too becomes a quote of that tweet's chirp. Retweets of
+// other tweets are still skipped: their text is somebody else's.
+func (cfg *apiConfig) importTwitterArchive(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Archive is too large", nil)
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Missing file", err)
+		return
+	}
+	defer file.Close()
+
+	retweets := r.FormValue("retweets")
+	if retweets != "" && retweets != "skip" && retweets != "quote" {
+		respondWithError(w, http.StatusBadRequest, "retweets must be skip or quote", nil)
+		return
+	}
+
+	a, err := twitter.Open(file, header.Size)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	limit, err := cfg.chirpLengthLimit(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving user", err)
+		return
+	}
+
+	result := TwitterImportResult{Imported: []ImportedTweet{}, Skipped: []SkippedTweet{}}
+	ids := map[uuid.UUID]uuid.UUID{}
+	for _, tweet := range a.Tweets {
+		if tweet.RetweetOf != "" {
+			if retweets != "quote" {
+				result.Skipped = append(result.Skipped, SkippedTweet{TweetID: tweet.ID, Reason: skipRetweet})
+				continue
+			}
+			if _, ok := ids[tweetChirpID(userID, tweet.RetweetedID)]; tweet.RetweetedID == "" || !ok {
+				result.Skipped = append(result.Skipped, SkippedTweet{TweetID: tweet.ID, Reason: skipRetweetTarget})
+				continue
+			}
+		}
+		record, ok := tweetRecord(userID, tweet)
+		if !ok {
+			result.Skipped = append(result.Skipped, SkippedTweet{TweetID: tweet.ID, Reason: skipMedia})
+			continue
+		}
+
+		// the handles in tweets are Twitter's, so they are not linked to
+		// the Chirpy users who happen to hold them
+		chirp, err := cfg.importChirp(r.Context(), userID, limit, a, record, ids, false)
+		var skip *importSkipError
+		if errors.As(err, &skip) {
+			result.Skipped = append(result.Skipped, SkippedTweet{TweetID: tweet.ID, Reason: skip.Reason})
+			continue
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error importing tweets", err)
+			return
+		}
+		result.Imported = append(result.Imported, ImportedTweet{TweetID: tweet.ID, ChirpID: chirp.ID})
+	}
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// tweetRecord maps a tweet onto the chirp importChirp creates, a retweet
+// onto a quote of the retweeted tweet with no text of its own. It reports
+// false for tweets with videos, which Chirpy cannot host.
+func tweetRecord(userID uuid.UUID, tweet twitter.Tweet) (archive.Chirp, bool) {
+	record := archive.Chirp{
+		ID:         tweetChirpID(userID, tweet.ID),
+		CreatedAt:  tweet.CreatedAt,
+		UpdatedAt:  tweet.CreatedAt,
+		Body:       tweet.Text,
+		Visibility: visibilityPublic,
+	}
+	if tweet.RetweetOf != "" {
+		// the retweeted tweet carries the text and media
+		quoteOfID := tweetChirpID(userID, tweet.RetweetedID)
+		record.Body = ""
+		record.QuoteOfID = &quoteOfID
+		return record, true
+	}
+	if tweet.InReplyToID != "" {
+		// replies to other people's tweets lose their parent on import
+		parentID := tweetChirpID(userID, tweet.InReplyToID)
+		record.ParentID = &parentID
+	}
+	for _, m := range tweet.Media {
+		if m.Type != "photo" {
+			return archive.Chirp{}, false
+		}
+		record.Media = append(record.Media, archive.Media{File: m.File})
+	}
+	return record, true
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestImportTwitterArchiveQuotesRetweets(t *testing.T) {
+	cfg := testConfig(t)
+	user, token := createTestUser(t, cfg)
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	for name, content := range map[string]string{
+		"data/account.js": `window.YTD.account.part0 = [ { "account" : { "username" : "me" } } ]`,
+		"data/tweets.js": `window.YTD.tweets.part0 = [
+  { "tweet" : { "id_str" : "1", "created_at" : "Sat Jan 01 00:00:00 +0000 2011", "full_text" : "my own words" } },
+  { "tweet" : { "id_str" : "2", "created_at" : "Sun Jan 02 00:00:00 +0000 2011", "full_text" : "RT @me: my own words" } },
+  { "tweet" : { "id_str" : "3", "created_at" : "Mon Jan 03 00:00:00 +0000 2011", "full_text" : "RT @someone: their words" } }
+]`,
+	} {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("retweets", "quote")
+	part, err := mw.CreateFormFile("file", "twitter.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(zipped.Bytes())
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", "/api/users/me/import/twitter", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	cfg.importTwitterArchive(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var result TwitterImportResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Imported) != 2 || result.Imported[0].TweetID != "1" || result.Imported[1].TweetID != "2" {
+		t.Fatalf("expected tweets 1 and 2 imported, got %+v", result.Imported)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].TweetID != "3" || result.Skipped[0].Reason != skipRetweetTarget {
+		t.Fatalf("expected tweet 3 skipped as %s, got %+v", skipRetweetTarget, result.Skipped)
+	}
+
+	quote, err := cfg.DB.GetChirpByID(context.Background(), result.Imported[1].ChirpID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.UserID != user.ID || quote.Body != "" || !quote.QuoteOfID.Valid || quote.QuoteOfID.UUID != result.Imported[0].ChirpID {
+		t.Fatalf("expected an empty quote of %s, got %+v", result.Imported[0].ChirpID, quote)
+	}
+}